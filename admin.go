@@ -0,0 +1,138 @@
+package ssgo
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ConfirmFlushDB must be passed to FlushDB for it to run.
+const ConfirmFlushDB = "yes, delete all data"
+
+// ErrFlushNotConfirmed is returned by FlushDB when called without
+// ConfirmFlushDB.
+var ErrFlushNotConfirmed = errors.New("ssgo: flushdb not confirmed")
+
+// Info is the parsed reply of the info command.
+type Info struct {
+	Version    string
+	Links      int64
+	TotalCalls int64
+	DBSize     int64
+	// Binlogs holds the binlog queue fields, like "capacity" and "max_seq".
+	Binlogs map[string]string
+	// Replication has one entry per slave or master link. The first line of
+	// a link, like "slaveof 127.0.0.1:8889", is stored under its first word.
+	Replication []map[string]string
+	// LevelDBStats is the compaction table reported by LevelDB, as is.
+	LevelDBStats string
+	// Raw holds every other field of the reply, like "serv_key_range".
+	Raw map[string]string
+}
+
+// ParseInfo decodes the reply of the info command.
+func ParseInfo(r Reply) (*Info, error) {
+	// The reply starts with the server name, then alternates names and values.
+	if len(r)%2 != 1 {
+		return nil, badReply("info", r)
+	}
+	info := &Info{Binlogs: map[string]string{}, Raw: map[string]string{}}
+	for i := 1; i < len(r); i += 2 {
+		k, v := r[i], r[i+1]
+		var err error
+		switch k {
+		case "version":
+			info.Version = v
+		case "links":
+			info.Links, err = strconv.ParseInt(v, 10, 64)
+		case "total_calls":
+			info.TotalCalls, err = strconv.ParseInt(v, 10, 64)
+		case "dbsize":
+			info.DBSize, err = strconv.ParseInt(v, 10, 64)
+		case "binlogs":
+			info.Binlogs = parseInfoBlock(v)
+		case "replication":
+			info.Replication = append(info.Replication, parseInfoBlock(v))
+		case "leveldb.stats":
+			info.LevelDBStats = v
+		default:
+			info.Raw[k] = v
+		}
+		if err != nil {
+			return nil, badReply("info", r[i:i+2])
+		}
+	}
+	return info, nil
+}
+
+// parseInfoBlock splits the "name : value" lines of a multi-line info field.
+func parseInfoBlock(s string) map[string]string {
+	m := map[string]string{}
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		sep := " :"
+		if !strings.Contains(line, sep) {
+			sep = " "
+		}
+		k, v, _ := strings.Cut(line, sep)
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return m
+}
+
+// Info returns the server status.
+func (c commands) Info() (*Info, error) {
+	r, err := c.call("info")
+	if err != nil {
+		return nil, err
+	}
+	return ParseInfo(r)
+}
+
+// DBSize returns the approximate size of the data on disk, in bytes.
+func (c commands) DBSize() (int64, error) {
+	r, err := c.call("dbsize")
+	return replyInt64("dbsize", r, err)
+}
+
+// Compact compacts the whole database. It blocks until the server is done.
+func (c commands) Compact() error {
+	_, err := c.call("compact")
+	return err
+}
+
+// KeyRange returns the first and last keys of each data type, as reported
+// by the key_range command.
+func (c commands) KeyRange() ([]string, error) {
+	return replyList(c.call("key_range"))
+}
+
+// ListAllowIP returns the IP prefixes allowed to connect.
+func (c commands) ListAllowIP() ([]string, error) {
+	return replyList(c.call("list_allow_ip"))
+}
+
+// AddAllowIP allows connections from the IP prefix rule.
+func (c commands) AddAllowIP(rule string) error {
+	_, err := c.call("add_allow_ip", rule)
+	return err
+}
+
+// DelAllowIP removes the IP prefix rule from the allowed list.
+func (c commands) DelAllowIP(rule string) error {
+	_, err := c.call("del_allow_ip", rule)
+	return err
+}
+
+// FlushDB deletes all data on the server. It does nothing but return
+// ErrFlushNotConfirmed unless confirm is ConfirmFlushDB.
+func (c commands) FlushDB(confirm string) error {
+	if confirm != ConfirmFlushDB {
+		return ErrFlushNotConfirmed
+	}
+	_, err := c.call("flushdb")
+	return err
+}
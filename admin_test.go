@@ -0,0 +1,64 @@
+package ssgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+var infoReply = Reply{
+	"ssdb-server",
+	"version", "1.9.4",
+	"links", "2",
+	"total_calls", "1234",
+	"dbsize", "5678",
+	"binlogs", "    capacity : 20000000\n    min_seq  : 1\n    max_seq  : 99",
+	"replication", "slaveof 127.0.0.1:8889\n    id         : svc_2\n    type       : sync\n    status     : SYNC",
+	"serv_key_range", "    kv  : \"\" - \"\"",
+	"leveldb.stats", "Compactions\nLevel  Files Size(MB)",
+}
+
+func TestParseInfo(t *testing.T) {
+	info, err := ParseInfo(infoReply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Info{
+		Version:    "1.9.4",
+		Links:      2,
+		TotalCalls: 1234,
+		DBSize:     5678,
+		Binlogs:    map[string]string{"capacity": "20000000", "min_seq": "1", "max_seq": "99"},
+		Replication: []map[string]string{{
+			"slaveof": "127.0.0.1:8889",
+			"id":      "svc_2",
+			"type":    "sync",
+			"status":  "SYNC",
+		}},
+		LevelDBStats: "Compactions\nLevel  Files Size(MB)",
+		Raw:          map[string]string{"serv_key_range": "    kv  : \"\" - \"\""},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Fatalf("ParseInfo returned %+v, want %+v", info, want)
+	}
+
+	if _, err := ParseInfo(infoReply[:2]); err == nil {
+		t.Fatal("expected an error for a truncated reply")
+	}
+}
+
+func TestFlushDBConfirm(t *testing.T) {
+	flushed := false
+	srv := newTestServer(t, func(req []string) []string {
+		flushed = flushed || req[0] == "flushdb"
+		return []string{"ok"}
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	if err := p.FlushDB("yes"); err != ErrFlushNotConfirmed {
+		t.Fatalf("FlushDB returned %v, want %v", err, ErrFlushNotConfirmed)
+	}
+	if err := p.FlushDB(ConfirmFlushDB); err != nil || !flushed {
+		t.Fatalf("FlushDB returned %v, flushed %v", err, flushed)
+	}
+}
@@ -0,0 +1,177 @@
+package ssgo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by a ConPool whose circuit breaker tripped,
+// without trying the server, see BreakerPolicy.
+var ErrCircuitOpen = errors.New("ssgo: circuit open")
+
+// Defaults of the BreakerPolicy fields.
+const (
+	DefaultBreakerFailures    = 5
+	DefaultBreakerMinRequests = 10
+	DefaultBreakerWindow      = 10 * time.Second
+	DefaultBreakerOpenTimeout = 5 * time.Second
+)
+
+// BreakerPolicy makes a ConPool fail fast with ErrCircuitOpen once the
+// server looks down, instead of dialing it for every command. Failed dials
+// and commands failing on a network error count as failures; error replies
+// do not. While open, the first command after OpenTimeout pings the server
+// on a new connection and closes the circuit again if it answers.
+type BreakerPolicy struct {
+	// ConsecutiveFailures trips the breaker after that many failures in a
+	// row. Defaults to DefaultBreakerFailures unless FailureRate is set.
+	ConsecutiveFailures int
+	// FailureRate trips the breaker when the share of failures within
+	// Window reaches it, once MinRequests were counted. Zero disables it.
+	FailureRate float64
+	MinRequests int
+	Window      time.Duration
+	// OpenTimeout is how long the breaker stays open before probing.
+	OpenTimeout time.Duration
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker is the state of the circuit breaker of a ConPool.
+type breaker struct {
+	p BreakerPolicy
+
+	mu          sync.Mutex
+	state       breakerState
+	openedAt    time.Time
+	consecutive int
+	windowStart time.Time
+	total       int
+	failures    int
+}
+
+func newBreaker(p BreakerPolicy) *breaker {
+	if p.ConsecutiveFailures <= 0 && p.FailureRate <= 0 {
+		p.ConsecutiveFailures = DefaultBreakerFailures
+	}
+	if p.MinRequests <= 0 {
+		p.MinRequests = DefaultBreakerMinRequests
+	}
+	if p.Window <= 0 {
+		p.Window = DefaultBreakerWindow
+	}
+	if p.OpenTimeout <= 0 {
+		p.OpenTimeout = DefaultBreakerOpenTimeout
+	}
+	return &breaker{p: p}
+}
+
+// record counts the outcome of a request and reports whether it tripped the
+// breaker. Outcomes are ignored unless the breaker is closed.
+func (b *breaker) record(failed bool, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != breakerClosed {
+		return false
+	}
+	if now.Sub(b.windowStart) > b.p.Window {
+		b.windowStart, b.total, b.failures = now, 0, 0
+	}
+	b.total++
+	if !failed {
+		b.consecutive = 0
+		return false
+	}
+	b.failures++
+	b.consecutive++
+	p := b.p
+	if (p.ConsecutiveFailures > 0 && b.consecutive >= p.ConsecutiveFailures) ||
+		(p.FailureRate > 0 && b.total >= p.MinRequests && float64(b.failures)/float64(b.total) >= p.FailureRate) {
+		b.state, b.openedAt = breakerOpen, now
+		return true
+	}
+	return false
+}
+
+// reset closes the circuit and clears the counters. b.mu must be held.
+func (b *breaker) reset() {
+	b.state = breakerClosed
+	b.consecutive, b.total, b.failures = 0, 0, 0
+	b.windowStart = time.Time{}
+}
+
+// allow returns ErrCircuitOpen while the breaker of cr is open. Past
+// OpenTimeout, the first caller probes the server and goes on if it is
+// back.
+func (cr *ConPool) allow(ctx context.Context) error {
+	b := cr.breaker
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	if b.state == breakerClosed {
+		b.mu.Unlock()
+		return nil
+	}
+	if b.state == breakerHalfOpen || time.Since(b.openedAt) < b.p.OpenTimeout {
+		b.mu.Unlock()
+		return ErrCircuitOpen
+	}
+	b.state = breakerHalfOpen
+	b.mu.Unlock()
+
+	err := cr.probe(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.reset()
+		cr.opts.log(slog.LevelInfo, "ssgo: circuit closed", "addr", cr.cAddr)
+		return nil
+	}
+	b.state = breakerOpen
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// let the next caller probe
+		return ctxErr
+	}
+	b.openedAt = time.Now()
+	cr.opts.log(slog.LevelWarn, "ssgo: circuit probe failed", "addr", cr.cAddr, "err", err)
+	return ErrCircuitOpen
+}
+
+// probe pings the server on a new connection, outside of the pool and of
+// the interceptors. The ping is bounded by Options.DialTimeout, so a server
+// that never answers cannot leave the breaker half-open.
+func (cr *ConPool) probe(ctx context.Context) error {
+	cn, err := dial(ctx, cr.cType, cr.cAddr, cr.opts)
+	if err != nil {
+		return err
+	}
+	defer cn.close()
+	ctx, cancel := context.WithTimeout(ctx, cr.opts.DialTimeout)
+	defer cancel()
+	_, err = cn.invoke(ctx, []interface{}{"ping"})
+	return err
+}
+
+// observe feeds the outcome of a dial or command to the breaker of cr.
+// Cancelled contexts say nothing about the server and are left out.
+func (cr *ConPool) observe(err error) {
+	b := cr.breaker
+	if b == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	if b.record(IsRetryable(err), time.Now()) {
+		cr.stats.breakerTrips.Add(1)
+		cr.opts.log(slog.LevelWarn, "ssgo: circuit open", "addr", cr.cAddr, "err", err)
+	}
+}
@@ -0,0 +1,144 @@
+package ssgo
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	srv := okServer(t)
+	var down atomic.Bool
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if down.Load() {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+	p := NewConPool(srv.Addr(), 2, WithDialer(dialer),
+		WithBreaker(BreakerPolicy{ConsecutiveFailures: 2, OpenTimeout: 50 * time.Millisecond}))
+	defer p.Close()
+
+	down.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, err := p.Do("ping"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Do returned %v, want a dial error", err)
+		}
+	}
+	if _, err := p.Do("ping"); err != ErrCircuitOpen {
+		t.Fatalf("Do returned %v, want %v", err, ErrCircuitOpen)
+	}
+	if _, err := p.GetClient(); err != ErrCircuitOpen {
+		t.Fatalf("GetClient returned %v, want %v", err, ErrCircuitOpen)
+	}
+	if st := p.Stats(); st.Dials != 2 || st.BreakerTrips != 1 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+
+	// the probe fails while the server is still down
+	time.Sleep(60 * time.Millisecond)
+	if _, err := p.Do("ping"); err != ErrCircuitOpen {
+		t.Fatalf("Do returned %v, want %v", err, ErrCircuitOpen)
+	}
+	if _, err := p.Do("ping"); err != ErrCircuitOpen {
+		t.Fatalf("Do returned %v, want %v", err, ErrCircuitOpen)
+	}
+
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := p.Do("ping"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Do("ping"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBreakerFailureRate(t *testing.T) {
+	b := newBreaker(BreakerPolicy{FailureRate: 0.5, MinRequests: 4, Window: time.Minute})
+	now := time.Now()
+	for i, failed := range []bool{true, false, true} {
+		if b.record(failed, now) {
+			t.Fatalf("tripped at request %d", i)
+		}
+	}
+	if !b.record(true, now) {
+		t.Fatal("not tripped at 3 failures out of 4")
+	}
+	if b.record(true, now) {
+		t.Fatal("open breaker tripped again")
+	}
+
+	// failures of a past window are forgotten
+	b.reset()
+	b.record(true, now)
+	b.record(true, now)
+	later := now.Add(2 * time.Minute)
+	for i := 0; i < 4; i++ {
+		if b.record(i == 0, later) {
+			t.Fatalf("tripped at request %d of the new window", i)
+		}
+	}
+}
+
+func TestCircuitBreakerStalledServer(t *testing.T) {
+	stalled := newTestServer(t, func(req []string) []string { return nil })
+	p := NewConPool(stalled.Addr(), 4, WithReadTimeout(10*time.Millisecond),
+		WithBreaker(BreakerPolicy{ConsecutiveFailures: 3, OpenTimeout: time.Minute}))
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := p.Do("ping"); !errors.Is(err, ErrTimeout) {
+			t.Fatalf("Do returned %v, want %v", err, ErrTimeout)
+		}
+	}
+	if _, err := p.Do("ping"); err != ErrCircuitOpen {
+		t.Fatalf("Do returned %v, want %v", err, ErrCircuitOpen)
+	}
+	if st := p.Stats(); st.BreakerTrips != 1 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestCircuitBreakerProbeTimeout(t *testing.T) {
+	stalled := newTestServer(t, func(req []string) []string { return nil })
+	ok := okServer(t)
+	var down, healed atomic.Bool
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if down.Load() {
+			return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+		}
+		if healed.Load() {
+			addr = ok.Addr()
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+	// no ReadTimeout: only DialTimeout bounds the probe
+	p := NewConPool(stalled.Addr(), 2, WithDialer(dialer), WithDialTimeout(50*time.Millisecond),
+		WithBreaker(BreakerPolicy{ConsecutiveFailures: 1, OpenTimeout: 20 * time.Millisecond}))
+	defer p.Close()
+
+	down.Store(true)
+	p.Do("ping")
+	down.Store(false)
+	time.Sleep(30 * time.Millisecond)
+	start := time.Now()
+	if _, err := p.Do("ping"); err != ErrCircuitOpen {
+		t.Fatalf("Do returned %v, want %v", err, ErrCircuitOpen)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("probe took %v", d)
+	}
+
+	// the breaker is open again, not stuck half-open
+	healed.Store(true)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := p.Do("ping"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,128 @@
+package ssgo
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// commands implements the typed command methods shared by Client and
+// ConPool on top of their Do.
+type commands struct {
+	call func(args ...interface{}) (Reply, error)
+}
+
+func badReply(cmd string, r Reply) error {
+	return fmt.Errorf("%w: %s: %q", ErrBadReply, cmd, []string(r))
+}
+
+// replyString decodes a reply holding a single value.
+func replyString(cmd string, r Reply, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if len(r) != 1 {
+		return "", badReply(cmd, r)
+	}
+	return r[0], nil
+}
+
+// replyInt64 decodes a reply holding a single integer.
+func replyInt64(cmd string, r Reply, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	if len(r) != 1 {
+		return 0, badReply(cmd, r)
+	}
+	n, err := strconv.ParseInt(r[0], 10, 64)
+	if err != nil {
+		return 0, badReply(cmd, r)
+	}
+	return n, nil
+}
+
+// replyFloat64 decodes a reply holding a single number.
+func replyFloat64(cmd string, r Reply, err error) (float64, error) {
+	if err != nil {
+		return 0, err
+	}
+	if len(r) != 1 {
+		return 0, badReply(cmd, r)
+	}
+	f, err := strconv.ParseFloat(r[0], 64)
+	if err != nil {
+		return 0, badReply(cmd, r)
+	}
+	return f, nil
+}
+
+// replyBool decodes a reply holding a single integer as n != 0.
+func replyBool(cmd string, r Reply, err error) (bool, error) {
+	n, err := replyInt64(cmd, r, err)
+	return n != 0, err
+}
+
+// replyMap decodes a reply of alternating keys and values.
+func replyMap(cmd string, r Reply, err error) (map[string]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(r)%2 != 0 {
+		return nil, badReply(cmd, r)
+	}
+	return r.Map(), nil
+}
+
+// replyList decodes a reply of plain values.
+func replyList(r Reply, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	return r.List(), nil
+}
+
+// replyEntries decodes a reply of alternating keys and values.
+func replyEntries(cmd string, r Reply, err error) ([]Entry, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(r)%2 != 0 {
+		return nil, badReply(cmd, r)
+	}
+	hs := r.Hash()
+	es := make([]Entry, len(hs))
+	for i, h := range hs {
+		es[i] = *h
+	}
+	return es, nil
+}
+
+// replyZEntries decodes a reply of alternating keys and scores.
+func replyZEntries(cmd string, r Reply, err error) ([]ZEntry, error) {
+	if err != nil {
+		return nil, err
+	}
+	zs, err := r.ZEntries()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cmd, err)
+	}
+	return zs, nil
+}
+
+// seconds converts d to the whole seconds SSDB expects for a TTL, rounding
+// up so that a sub-second TTL does not become 0.
+func seconds(d time.Duration) int64 {
+	if d <= 0 {
+		return int64(d / time.Second)
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+// appendStrings appends ss to args one by one.
+func appendStrings(args []interface{}, ss []string) []interface{} {
+	for _, s := range ss {
+		args = append(args, s)
+	}
+	return args
+}
@@ -0,0 +1,125 @@
+package ssgo
+
+// HSet sets field key of hash name to val.
+func (c commands) HSet(name, key string, val interface{}) error {
+	_, err := c.call("hset", name, key, val)
+	return err
+}
+
+// HGet returns field key of hash name. The error matches ErrNotFound if
+// the field does not exist.
+func (c commands) HGet(name, key string) (string, error) {
+	r, err := c.call("hget", name, key)
+	return replyString("hget", r, err)
+}
+
+// HDel deletes field key of hash name.
+func (c commands) HDel(name, key string) error {
+	_, err := c.call("hdel", name, key)
+	return err
+}
+
+// HIncr adds n to the integer field key of hash name and returns the new
+// value.
+func (c commands) HIncr(name, key string, n int64) (int64, error) {
+	r, err := c.call("hincr", name, key, n)
+	return replyInt64("hincr", r, err)
+}
+
+// HExists reports whether field key of hash name exists.
+func (c commands) HExists(name, key string) (bool, error) {
+	r, err := c.call("hexists", name, key)
+	return replyBool("hexists", r, err)
+}
+
+// HSize returns the number of fields in hash name.
+func (c commands) HSize(name string) (int64, error) {
+	r, err := c.call("hsize", name)
+	return replyInt64("hsize", r, err)
+}
+
+// HKeys returns up to limit field names of hash name in (start, end].
+// Empty bounds are open.
+func (c commands) HKeys(name, start, end string, limit int) ([]string, error) {
+	return replyList(c.call("hkeys", name, start, end, limit))
+}
+
+// HGetAll returns every field of hash name.
+func (c commands) HGetAll(name string) (map[string]string, error) {
+	r, err := c.call("hgetall", name)
+	return replyMap("hgetall", r, err)
+}
+
+// HScan returns up to limit fields of hash name in (start, end], in
+// ascending order. Empty bounds are open.
+func (c commands) HScan(name, start, end string, limit int) ([]Entry, error) {
+	r, err := c.call("hscan", name, start, end, limit)
+	return replyEntries("hscan", r, err)
+}
+
+// HRScan is like HScan, in descending order.
+func (c commands) HRScan(name, start, end string, limit int) ([]Entry, error) {
+	r, err := c.call("hrscan", name, start, end, limit)
+	return replyEntries("hrscan", r, err)
+}
+
+// HList returns up to limit hash names in (start, end], in ascending order.
+func (c commands) HList(start, end string, limit int) ([]string, error) {
+	return replyList(c.call("hlist", start, end, limit))
+}
+
+// HRList is like HList, in descending order.
+func (c commands) HRList(start, end string, limit int) ([]string, error) {
+	return replyList(c.call("hrlist", start, end, limit))
+}
+
+// HClear deletes hash name and returns the number of fields it had.
+func (c commands) HClear(name string) (int64, error) {
+	r, err := c.call("hclear", name)
+	return replyInt64("hclear", r, err)
+}
+
+func (c commands) MultiHSet(name string, obj interface{}, keys ...string) error {
+	args := Args{"multi_hset", name}.AddFlat(obj, keys...)
+	_, e := c.call(args...)
+	return e
+}
+
+// MultiHGet scans the fields keys, or all fields, of hash name into obj. It
+// returns an error matching ErrNotFound if none of them exist.
+func (c commands) MultiHGet(name string, obj interface{}, keys ...string) error {
+	args := []interface{}{"hgetall", name}
+	if len(keys) > 0 {
+		args[0] = "multi_hget"
+		for _, v := range keys {
+			args = append(args, v)
+		}
+	}
+	rep, e := c.call(args...)
+	if e != nil {
+		return e
+	}
+	if len(rep) == 0 {
+		return &StatusError{Cmd: cmdName(args), Status: ReplyNotFound}
+	}
+	return ScanStruct(rep, obj)
+}
+
+// MultiHGetMap returns the fields keys of hash name; missing fields are
+// left out.
+func (c commands) MultiHGetMap(name string, keys ...string) (map[string]string, error) {
+	if len(keys) == 0 {
+		return map[string]string{}, nil
+	}
+	r, err := c.call(appendStrings([]interface{}{"multi_hget", name}, keys)...)
+	return replyMap("multi_hget", r, err)
+}
+
+// MultiHDel deletes the fields keys of hash name.
+func (c commands) MultiHDel(name string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := c.call(appendStrings([]interface{}{"multi_hdel", name}, keys)...)
+	return err
+}
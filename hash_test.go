@@ -0,0 +1,60 @@
+package ssgo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHashCommands(t *testing.T) {
+	var last []string
+	srv := newTestServer(t, func(req []string) []string {
+		last = req
+		switch req[0] {
+		case "hget":
+			return []string{"not_found"}
+		case "hincr", "hsize":
+			return []string{"ok", "3"}
+		case "hscan", "hgetall", "multi_hget":
+			return []string{"ok", "a", "1", "b", "2"}
+		case "hrscan":
+			return []string{"ok", "a"}
+		case "hkeys":
+			return []string{"ok", "a", "b"}
+		}
+		return []string{"ok", "1"}
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	if _, err := p.HGet("h", "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("HGet returned %v, want %v", err, ErrNotFound)
+	}
+	if n, err := p.HIncr("h", "a", -2); n != 3 || err != nil {
+		t.Fatalf("HIncr returned %v, %v", n, err)
+	}
+	if !reflect.DeepEqual(last, []string{"hincr", "h", "a", "-2"}) {
+		t.Fatalf("sent %q", last)
+	}
+	es, err := p.HScan("h", "", "", 10)
+	if err != nil || !reflect.DeepEqual(es, []Entry{{"a", "1"}, {"b", "2"}}) {
+		t.Fatalf("HScan returned %v, %v", es, err)
+	}
+	if _, err := p.HRScan("h", "", "", 10); !errors.Is(err, ErrBadReply) {
+		t.Fatalf("HRScan returned %v, want %v", err, ErrBadReply)
+	}
+	keys, err := p.HKeys("h", "", "", 10)
+	if err != nil || !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Fatalf("HKeys returned %v, %v", keys, err)
+	}
+	m, err := p.MultiHGetMap("h", "a", "b")
+	if err != nil || !reflect.DeepEqual(m, map[string]string{"a": "1", "b": "2"}) {
+		t.Fatalf("MultiHGetMap returned %v, %v", m, err)
+	}
+	if err := p.MultiHDel("h", "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(last, []string{"multi_hdel", "h", "a", "b"}) {
+		t.Fatalf("sent %q", last)
+	}
+}
@@ -0,0 +1,127 @@
+package ssgo
+
+import "context"
+
+// Invoker runs a command and returns its reply.
+type Invoker func(ctx context.Context, cmd []interface{}) (Reply, error)
+
+// Interceptor wraps every command run by Do, DoContext, BatchDo and
+// BatchDoContext. It may inspect or rewrite cmd, call next any number of
+// times, or return without calling it at all.
+//
+// The commands of a batch go through the chain one at a time and in batch
+// order, so interceptors need no locking. The ones reaching the end of the
+// chain are pipelined together.
+type Interceptor func(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error)
+
+// chainInterceptors wraps final in is, the first being the outermost.
+func chainInterceptors(is []Interceptor, final Invoker) Invoker {
+	for i := len(is) - 1; i >= 0; i-- {
+		ic, next := is[i], final
+		final = func(ctx context.Context, cmd []interface{}) (Reply, error) {
+			return ic(ctx, cmd, next)
+		}
+	}
+	return final
+}
+
+// exec is the end of the interceptor chain for Do. It counts the command
+// for PoolStats.Commands before running it.
+func (c *Client) exec(ctx context.Context, cmd []interface{}) (Reply, error) {
+	if c.err == nil {
+		c.cmds++
+	}
+	return c.invoke(ctx, cmd)
+}
+
+// invoke runs cmd, bounded by ctx. Internal commands, like auth and the
+// TestOnBorrow ping, call it directly, so that they skip the interceptors
+// and are not counted.
+func (c *Client) invoke(ctx context.Context, cmd []interface{}) (Reply, error) {
+	stop, err := c.watchContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r, err := c.do(ctx, cmd)
+	return r, stop(err)
+}
+
+// interceptedCall is a batch command that went through the whole chain
+// and waits for its reply.
+type interceptedCall struct {
+	i     int
+	cmd   []interface{}
+	reply chan ReplyE
+}
+
+type interceptedResult struct {
+	i int
+	ReplyE
+}
+
+// pipelineIntercepted is pipeline with every command going through the
+// interceptor chain. Only one chain runs at a time: each one, in batch
+// order, runs up to its call to next before the following one starts. The
+// commands that got through are then pipelined together, and each chain
+// resumes as soon as its own reply is read. Later calls to next, by an
+// interceptor retrying for instance, are run one by one afterwards.
+func (c *Client) pipelineIntercepted(ctx context.Context, cmds BatchExec, reps []ReplyE) {
+	arrived := make(chan *interceptedCall)
+	results := make(chan interceptedResult)
+	var pending []*interceptedCall
+	// await blocks until the running chain returns or calls next.
+	await := func() *interceptedCall {
+		select {
+		case call := <-arrived:
+			return call
+		case res := <-results:
+			reps[res.i] = res.ReplyE
+			return nil
+		}
+	}
+	// resume hands re to call and waits for its chain again.
+	resume := func(call *interceptedCall, re ReplyE) {
+		call.reply <- re
+		if again := await(); again != nil {
+			pending = append(pending, again)
+		}
+	}
+
+	var calls []*interceptedCall
+	for i := range cmds {
+		go func(i int) {
+			next := func(ctx context.Context, cmd []interface{}) (Reply, error) {
+				call := &interceptedCall{i: i, cmd: cmd, reply: make(chan ReplyE, 1)}
+				arrived <- call
+				re := <-call.reply
+				return re.R, re.E
+			}
+			r, err := chainInterceptors(c.opts.Interceptors, next)(ctx, cmds[i])
+			results <- interceptedResult{i, ReplyE{r, err}}
+		}(i)
+		if call := await(); call != nil {
+			calls = append(calls, call)
+		}
+	}
+
+	for len(calls) > 0 {
+		batch := make(BatchExec, len(calls))
+		for k, call := range calls {
+			batch[k] = call.cmd
+		}
+		r := make([]ReplyE, len(calls))
+		if err := contextErr(ctx); err != nil {
+			for k, call := range calls {
+				r[k].E = err
+				resume(call, r[k])
+			}
+		} else {
+			c.pipeline(ctx, batch, r, func(k int) { resume(calls[k], r[k]) })
+		}
+		calls = nil
+		if len(pending) > 0 {
+			// calls made again are run one by one
+			calls, pending = pending[:1:1], pending[1:]
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package ssgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestInterceptors(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		return append([]string{"ok"}, req[1:]...)
+	})
+
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	logging := func(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error) {
+		mu.Lock()
+		seen = append(seen, fmt.Sprint(cmd))
+		mu.Unlock()
+		return next(ctx, cmd)
+	}
+	prefix := func(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error) {
+		if len(cmd) > 1 {
+			cmd = append([]interface{}{cmd[0], "app:" + fmt.Sprint(cmd[1])}, cmd[2:]...)
+		}
+		return next(ctx, cmd)
+	}
+	errInjected := errors.New("injected")
+	fault := func(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error) {
+		if cmd[0] == "del" {
+			return nil, errInjected
+		}
+		return next(ctx, cmd)
+	}
+	retried := 0
+	retry := func(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error) {
+		r, err := next(ctx, cmd)
+		if cmd[0] == "incr" {
+			mu.Lock()
+			retried++
+			mu.Unlock()
+			return next(ctx, cmd)
+		}
+		return r, err
+	}
+
+	p := NewConPool(srv.Addr(), 1, WithInterceptors(logging, fault, prefix, retry))
+	defer p.Close()
+
+	if r, err := p.Do("get", "k"); err != nil || r.String() != "app:k" {
+		t.Fatalf("Do returned %v, %v", r, err)
+	}
+
+	reps, err := p.BatchDo(BatchExec{{"get", "a"}, {"del", "b"}, {"incr", "c"}, {"get", "d"}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := []string{"app:a", "", "app:c", "app:d"}
+	for i, r := range reps {
+		if r.R.String() != want[i] {
+			t.Errorf("reply %d: %v %v, want %v", i, r.R, r.E, want[i])
+		}
+	}
+	if reps[1].E != errInjected {
+		t.Errorf("reply 1: %v, want %v", reps[1].E, errInjected)
+	}
+	if retried != 1 {
+		t.Errorf("incr retried %d times", retried)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 5 || seen[0] != "[get k]" {
+		t.Fatalf("logged %q", seen)
+	}
+	if p.Stats().Commands != 5 {
+		t.Fatalf("ran %d commands, want 5", p.Stats().Commands)
+	}
+}
+
+func TestInterceptorsBatchOrder(t *testing.T) {
+	srv := okServer(t)
+	// no locking: the chains of a batch never run concurrently
+	var events []string
+	trace := func(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error) {
+		events = append(events, "pre "+fmt.Sprint(cmd[1]))
+		r, err := next(ctx, cmd)
+		events = append(events, "post "+fmt.Sprint(cmd[1]))
+		return r, err
+	}
+	p := NewConPool(srv.Addr(), 1, WithInterceptors(trace))
+	defer p.Close()
+
+	if _, err := p.BatchDo(BatchExec{{"get", "a"}, {"get", "b"}, {"get", "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "[pre a pre b pre c post a post b post c]"
+	if got := fmt.Sprint(events); got != want {
+		t.Fatalf("events %s, want %s", got, want)
+	}
+}
+
+func TestInterceptorsSkipInternalCommands(t *testing.T) {
+	srv := newAuthTestServer(t, "s3cret", func(req []string) []string {
+		return []string{"ok"}
+	})
+	var seen []string
+	record := func(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error) {
+		seen = append(seen, fmt.Sprint(cmd))
+		return next(ctx, cmd)
+	}
+	p := NewConPool(srv.Addr(), 1, WithPassword("s3cret"), WithTestOnBorrow(true), WithInterceptors(record))
+	defer p.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.Do("get", "k"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fmt.Sprint(seen); got != "[[get k] [get k]]" {
+		t.Fatalf("intercepted %s", got)
+	}
+}
@@ -0,0 +1,141 @@
+package ssgo
+
+import "time"
+
+// Set sets key to val.
+func (c commands) Set(key string, val interface{}) error {
+	_, err := c.call("set", key, val)
+	return err
+}
+
+// Get returns the value of key. The error matches ErrNotFound if key does
+// not exist.
+func (c commands) Get(key string) (string, error) {
+	r, err := c.call("get", key)
+	return replyString("get", r, err)
+}
+
+// Del deletes key.
+func (c commands) Del(key string) error {
+	_, err := c.call("del", key)
+	return err
+}
+
+// SetX sets key to val, expiring after ttl, rounded up to whole seconds.
+func (c commands) SetX(key string, val interface{}, ttl time.Duration) error {
+	_, err := c.call("setx", key, val, seconds(ttl))
+	return err
+}
+
+// SetNX sets key to val unless it exists, and reports whether it did.
+func (c commands) SetNX(key string, val interface{}) (bool, error) {
+	r, err := c.call("setnx", key, val)
+	return replyBool("setnx", r, err)
+}
+
+// GetSet sets key to val and returns its old value. The error matches
+// ErrNotFound if key did not exist; val is set regardless.
+func (c commands) GetSet(key string, val interface{}) (string, error) {
+	r, err := c.call("getset", key, val)
+	return replyString("getset", r, err)
+}
+
+// Incr adds 1 to the integer at key and returns the new value.
+func (c commands) Incr(key string) (int64, error) {
+	return c.IncrBy(key, 1)
+}
+
+// IncrBy adds n to the integer at key and returns the new value.
+func (c commands) IncrBy(key string, n int64) (int64, error) {
+	r, err := c.call("incr", key, n)
+	return replyInt64("incr", r, err)
+}
+
+// Exists reports whether key exists.
+func (c commands) Exists(key string) (bool, error) {
+	r, err := c.call("exists", key)
+	return replyBool("exists", r, err)
+}
+
+// Expire sets the time to live of key, rounded up to whole seconds, and
+// reports whether key exists.
+func (c commands) Expire(key string, ttl time.Duration) (bool, error) {
+	r, err := c.call("expire", key, seconds(ttl))
+	return replyBool("expire", r, err)
+}
+
+// TTL returns the time to live of key. It is negative if key does not
+// exist or does not expire.
+func (c commands) TTL(key string) (time.Duration, error) {
+	r, err := c.call("ttl", key)
+	n, err := replyInt64("ttl", r, err)
+	return time.Duration(n) * time.Second, err
+}
+
+// StrLen returns the length of the value at key.
+func (c commands) StrLen(key string) (int64, error) {
+	r, err := c.call("strlen", key)
+	return replyInt64("strlen", r, err)
+}
+
+// Substr returns size bytes of the value at key from start. A negative
+// start counts from the end, a negative size stops that many bytes before
+// the end.
+func (c commands) Substr(key string, start, size int) (string, error) {
+	r, err := c.call("substr", key, start, size)
+	return replyString("substr", r, err)
+}
+
+// MultiSet sets every key of kvs to its value.
+func (c commands) MultiSet(kvs map[string]interface{}) error {
+	if len(kvs) == 0 {
+		return nil
+	}
+	_, err := c.call(Args{"multi_set"}.AddFlat(kvs)...)
+	return err
+}
+
+// MultiGet returns the values of keys; missing keys are left out.
+func (c commands) MultiGet(keys ...string) (map[string]string, error) {
+	if len(keys) == 0 {
+		return map[string]string{}, nil
+	}
+	r, err := c.call(appendStrings([]interface{}{"multi_get"}, keys)...)
+	return replyMap("multi_get", r, err)
+}
+
+// MultiDel deletes keys.
+func (c commands) MultiDel(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := c.call(appendStrings([]interface{}{"multi_del"}, keys)...)
+	return err
+}
+
+// SetBit sets the bit at offset of the value at key, and returns its old
+// value.
+func (c commands) SetBit(key string, offset int64, on bool) (bool, error) {
+	r, err := c.call("setbit", key, offset, on)
+	return replyBool("setbit", r, err)
+}
+
+// GetBit returns the bit at offset of the value at key.
+func (c commands) GetBit(key string, offset int64) (bool, error) {
+	r, err := c.call("getbit", key, offset)
+	return replyBool("getbit", r, err)
+}
+
+// BitCount counts the set bits of the value at key between the bytes start
+// and end, both included. Negative positions count from the end.
+func (c commands) BitCount(key string, start, end int64) (int64, error) {
+	r, err := c.call("bitcount", key, start, end)
+	return replyInt64("bitcount", r, err)
+}
+
+// CountBit counts the set bits of size bytes of the value at key from
+// start. Negative positions count from the end.
+func (c commands) CountBit(key string, start, size int64) (int64, error) {
+	r, err := c.call("countbit", key, start, size)
+	return replyInt64("countbit", r, err)
+}
@@ -0,0 +1,143 @@
+package ssgo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKVCommands(t *testing.T) {
+	var last []string
+	srv := newTestServer(t, func(req []string) []string {
+		last = req
+		switch req[0] {
+		case "set", "del", "setx", "expire", "multi_set", "multi_del":
+			return []string{"ok", "1"}
+		case "get":
+			if req[1] == "missing" {
+				return []string{"not_found"}
+			}
+			return []string{"ok", "v"}
+		case "setnx", "exists":
+			return []string{"ok", "0"}
+		case "getset":
+			return []string{"not_found"}
+		case "incr":
+			return []string{"ok", "42"}
+		case "ttl":
+			return []string{"ok", "-1"}
+		case "strlen":
+			return []string{"ok", "not a number"}
+		case "multi_get":
+			return []string{"ok", "a", "1", "b", "2"}
+		}
+		return []string{"error"}
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	if err := p.SetX("k", 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(last, []string{"setx", "k", "1", "60"}) {
+		t.Fatalf("sent %q", last)
+	}
+	p.SetX("k", 1, 500*time.Millisecond)
+	if !reflect.DeepEqual(last, []string{"setx", "k", "1", "1"}) {
+		t.Fatalf("sent %q", last)
+	}
+	p.Expire("k", 1500*time.Millisecond)
+	if !reflect.DeepEqual(last, []string{"expire", "k", "2"}) {
+		t.Fatalf("sent %q", last)
+	}
+	if err := p.Set("k", 1); err != nil || !reflect.DeepEqual(last, []string{"set", "k", "1"}) {
+		t.Fatalf("Set returned %v, sent %q", err, last)
+	}
+	if v, err := p.Get("k"); v != "v" || err != nil {
+		t.Fatalf("Get returned %q, %v", v, err)
+	}
+	if _, err := p.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get returned %v, want %v", err, ErrNotFound)
+	}
+	if err := p.Del("k"); err != nil || !reflect.DeepEqual(last, []string{"del", "k"}) {
+		t.Fatalf("Del returned %v, sent %q", err, last)
+	}
+	if ok, err := p.SetNX("k", "v"); ok || err != nil {
+		t.Fatalf("SetNX returned %v, %v", ok, err)
+	}
+	if _, err := p.GetSet("k", "v"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetSet returned %v, want %v", err, ErrNotFound)
+	}
+	if n, err := p.IncrBy("k", 2); n != 42 || err != nil {
+		t.Fatalf("IncrBy returned %v, %v", n, err)
+	}
+	if d, err := p.TTL("k"); d >= 0 || err != nil {
+		t.Fatalf("TTL returned %v, %v", d, err)
+	}
+	if _, err := p.StrLen("k"); !errors.Is(err, ErrBadReply) {
+		t.Fatalf("StrLen returned %v, want %v", err, ErrBadReply)
+	}
+	m, err := p.MultiGet("a", "b", "c")
+	if err != nil || !reflect.DeepEqual(m, map[string]string{"a": "1", "b": "2"}) {
+		t.Fatalf("MultiGet returned %v, %v", m, err)
+	}
+	if err := p.MultiDel("a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(last, []string{"multi_del", "a", "b"}) {
+		t.Fatalf("sent %q", last)
+	}
+
+	cn, _ := p.GetClient()
+	defer cn.Release()
+	if err := cn.Set("k", 2); err != nil || !reflect.DeepEqual(last, []string{"set", "k", "2"}) {
+		t.Fatalf("Client.Set returned %v, sent %q", err, last)
+	}
+	if v, err := cn.Get("k"); v != "v" || err != nil {
+		t.Fatalf("Client.Get returned %q, %v", v, err)
+	}
+	if _, err := cn.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Client.Get returned %v, want %v", err, ErrNotFound)
+	}
+	if err := cn.Del("k"); err != nil || !reflect.DeepEqual(last, []string{"del", "k"}) {
+		t.Fatalf("Client.Del returned %v, sent %q", err, last)
+	}
+	if ok, err := cn.Exists("k"); ok || err != nil {
+		t.Fatalf("Exists returned %v, %v", ok, err)
+	}
+}
+
+func TestBitCommands(t *testing.T) {
+	var last []string
+	srv := newTestServer(t, func(req []string) []string {
+		last = req
+		switch req[0] {
+		case "setbit":
+			return []string{"ok", "0"}
+		case "getbit":
+			return []string{"ok", "1"}
+		case "bitcount":
+			return []string{"ok", "7"}
+		}
+		return []string{"ok", ""}
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	if old, err := p.SetBit("bits", 9, true); old || err != nil {
+		t.Fatalf("SetBit returned %v, %v", old, err)
+	}
+	if !reflect.DeepEqual(last, []string{"setbit", "bits", "9", "1"}) {
+		t.Fatalf("sent %q", last)
+	}
+	if on, err := p.GetBit("bits", 9); !on || err != nil {
+		t.Fatalf("GetBit returned %v, %v", on, err)
+	}
+	if n, err := p.BitCount("bits", 0, -1); n != 7 || err != nil {
+		t.Fatalf("BitCount returned %v, %v", n, err)
+	}
+	if _, err := p.CountBit("bits", 0, -1); !errors.Is(err, ErrBadReply) {
+		t.Fatalf("CountBit returned %v, want %v", err, ErrBadReply)
+	}
+}
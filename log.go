@@ -0,0 +1,68 @@
+package ssgo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// maxLoggedArg is the size above which arguments are redacted in logs.
+const maxLoggedArg = 64
+
+// log writes to o.Logger, if set.
+func (o *Options) log(level slog.Level, msg string, args ...any) {
+	if o.Logger != nil {
+		o.Logger.Log(context.Background(), level, msg, args...)
+	}
+}
+
+// logSlow is the interceptor logging commands slower than o.SlowThreshold.
+func (o *Options) logSlow(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error) {
+	start := time.Now()
+	r, err := next(ctx, cmd)
+	if d := time.Since(start); d > o.SlowThreshold {
+		name := cmdName(cmd)
+		attrs := []any{"cmd", name}
+		if !strings.EqualFold(name, "auth") {
+			// never log the password
+			attrs = append(attrs, "key", redact(cmdKey(cmd)), "args", redactArgs(cmd))
+		}
+		attrs = append(attrs, "duration", d, "reply_bytes", replySize(r))
+		if err != nil {
+			attrs = append(attrs, "err", err)
+		}
+		o.Logger.Log(ctx, slog.LevelWarn, "ssgo: slow command", attrs...)
+	}
+	return r, err
+}
+
+// redactArgs formats the arguments after the key, replacing large ones by
+// their size.
+func redactArgs(cmd []interface{}) []string {
+	if len(cmd) <= 2 {
+		return nil
+	}
+	args := make([]string, 0, len(cmd)-2)
+	for _, arg := range cmd[2:] {
+		var s string
+		switch arg := arg.(type) {
+		case string:
+			s = arg
+		case []byte:
+			s = string(arg)
+		default:
+			s = fmt.Sprint(arg)
+		}
+		args = append(args, redact(s))
+	}
+	return args
+}
+
+func redact(s string) string {
+	if len(s) > maxLoggedArg {
+		return fmt.Sprintf("<%d bytes>", len(s))
+	}
+	return s
+}
@@ -0,0 +1,79 @@
+package ssgo
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowLog(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		if req[0] == "set" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		return []string{"ok", "1"}
+	})
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	p := NewConPool(srv.Addr(), 1, WithLogger(logger), WithSlowThreshold(10*time.Millisecond))
+	defer p.Close()
+
+	if _, err := p.Do("get", "fast"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("fast command logged: %s", buf.String())
+	}
+	if _, err := p.Do("set", "slow", strings.Repeat("x", 100)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"level=WARN", `msg="ssgo: slow command"`, "cmd=set", "key=slow", `args="[<100 bytes>]"`, "reply_bytes=1"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("log %q missing %q", out, want)
+		}
+	}
+	if strings.Contains(out, "xxxx") {
+		t.Fatalf("large value not redacted: %s", out)
+	}
+}
+
+func TestLogDialFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	var buf bytes.Buffer
+	p := NewConPool(addr, 1, WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	defer p.Close()
+	if _, err := p.Do("get", "k"); err == nil {
+		t.Fatal("expected dial error")
+	}
+	if out := buf.String(); !strings.Contains(out, `msg="ssgo: dial failed"`) || !strings.Contains(out, "addr="+addr) {
+		t.Fatalf("log %q", out)
+	}
+}
+
+func TestSlowLogAuth(t *testing.T) {
+	srv := newAuthTestServer(t, "s3cret", func(req []string) []string {
+		return []string{"ok"}
+	})
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	p := NewConPool(srv.Addr(), 1, WithPassword("s3cret"), WithLogger(logger), WithSlowThreshold(time.Nanosecond))
+	defer p.Close()
+
+	if _, err := p.Do("auth", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "cmd=auth") || strings.Contains(out, "s3cret") {
+		t.Fatalf("log %q", out)
+	}
+}
@@ -0,0 +1,275 @@
+package ssgo
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the command
+// latency histograms kept by Metrics.
+var DefaultLatencyBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+// Metrics collects command latencies, errors, traffic and pool gauges of
+// the clients and pools it is given to with WithMetrics. It publishes them
+// with Publish, for expvar, and Handler, in the Prometheus text format.
+type Metrics struct {
+	buckets []float64
+
+	mu    sync.Mutex
+	cmds  map[string]*cmdMetrics
+	pools map[*ConPool]struct{}
+
+	bytesSent atomic.Int64
+	bytesRecv atomic.Int64
+}
+
+type cmdMetrics struct {
+	counts []int64 // per bucket, the last one for +Inf
+	sum    float64 // seconds
+	count  int64
+	errors map[string]int64 // by errorStatus
+}
+
+// NewMetrics returns an empty collector using DefaultLatencyBuckets.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		buckets: DefaultLatencyBuckets,
+		cmds:    map[string]*cmdMetrics{},
+		pools:   map[*ConPool]struct{}{},
+	}
+}
+
+// WithMetrics records every command, its bytes on the wire and, for a
+// ConPool, the pool gauges into m.
+func WithMetrics(m *Metrics) Option {
+	return func(o *Options) {
+		o.Metrics = m
+		o.Interceptors = append(o.Interceptors, m.intercept)
+	}
+}
+
+func (m *Metrics) intercept(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error) {
+	start := time.Now()
+	r, err := next(ctx, cmd)
+	m.observe(cmdName(cmd), time.Since(start), err)
+	return r, err
+}
+
+func (m *Metrics) observe(cmd string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cm := m.cmds[cmd]
+	if cm == nil {
+		cm = &cmdMetrics{counts: make([]int64, len(m.buckets)+1), errors: map[string]int64{}}
+		m.cmds[cmd] = cm
+	}
+	s := d.Seconds()
+	cm.counts[sort.SearchFloat64s(m.buckets, s)]++
+	cm.sum += s
+	cm.count++
+	if err != nil {
+		cm.errors[errorStatus(err)]++
+	}
+}
+
+// errorStatus names the kind of err: the SSDB status of a StatusError, or
+// one of "timeout", "canceled", "bad_reply" and "io".
+func errorStatus(err error) string {
+	var se *StatusError
+	switch {
+	case errors.As(err, &se):
+		return se.Status
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, ErrBadReply), errors.Is(err, ErrProtocolError):
+		return "bad_reply"
+	}
+	return "io"
+}
+
+func (m *Metrics) addPool(cr *ConPool) {
+	m.mu.Lock()
+	m.pools[cr] = struct{}{}
+	m.mu.Unlock()
+}
+
+func (m *Metrics) removePool(cr *ConPool) {
+	m.mu.Lock()
+	delete(m.pools, cr)
+	m.mu.Unlock()
+}
+
+// poolStats sums the stats of the registered pools by address.
+func (m *Metrics) poolStats() map[string]PoolStats {
+	m.mu.Lock()
+	pools := make([]*ConPool, 0, len(m.pools))
+	for cr := range m.pools {
+		pools = append(pools, cr)
+	}
+	m.mu.Unlock()
+
+	stats := map[string]PoolStats{}
+	for _, cr := range pools {
+		st, sum := cr.Stats(), stats[cr.cAddr]
+		sum.TotalConns += st.TotalConns
+		sum.IdleConns += st.IdleConns
+		sum.InUse += st.InUse
+		sum.Dials += st.Dials
+		sum.DialFailures += st.DialFailures
+		sum.Waits += st.Waits
+		sum.WaitDuration += st.WaitDuration
+		sum.ClosedErrors += st.ClosedErrors
+		sum.ClosedStale += st.ClosedStale
+		sum.Commands += st.Commands
+		sum.Retries += st.Retries
+		sum.BreakerTrips += st.BreakerTrips
+		stats[cr.cAddr] = sum
+	}
+	return stats
+}
+
+// Publish exports the metrics under name with expvar. Like expvar.Publish,
+// it panics if name is already taken.
+func (m *Metrics) Publish(name string) {
+	expvar.Publish(name, expvar.Func(m.expvar))
+}
+
+func (m *Metrics) expvar() interface{} {
+	type cmdVar struct {
+		Count      int64            `json:"count"`
+		SumSeconds float64          `json:"sum_seconds"`
+		Errors     map[string]int64 `json:"errors"`
+	}
+	cmds := map[string]cmdVar{}
+	m.mu.Lock()
+	for name, cm := range m.cmds {
+		errs := make(map[string]int64, len(cm.errors))
+		for k, v := range cm.errors {
+			errs[k] = v
+		}
+		cmds[name] = cmdVar{cm.count, cm.sum, errs}
+	}
+	m.mu.Unlock()
+	return map[string]interface{}{
+		"commands":       cmds,
+		"bytes_sent":     m.bytesSent.Load(),
+		"bytes_received": m.bytesRecv.Load(),
+		"pools":          m.poolStats(),
+	}
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition
+// format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+
+	m.mu.Lock()
+	names := make([]string, 0, len(m.cmds))
+	for name := range m.cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b.WriteString("# HELP ssgo_command_duration_seconds Latency of SSDB commands.\n")
+	b.WriteString("# TYPE ssgo_command_duration_seconds histogram\n")
+	for _, name := range names {
+		cm, l := m.cmds[name], label("cmd", name)
+		var n int64
+		for i, le := range m.buckets {
+			n += cm.counts[i]
+			fmt.Fprintf(&b, "ssgo_command_duration_seconds_bucket{%s,le=%q} %d\n", l, formatFloat(le), n)
+		}
+		fmt.Fprintf(&b, "ssgo_command_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", l, cm.count)
+		fmt.Fprintf(&b, "ssgo_command_duration_seconds_sum{%s} %s\n", l, formatFloat(cm.sum))
+		fmt.Fprintf(&b, "ssgo_command_duration_seconds_count{%s} %d\n", l, cm.count)
+	}
+
+	b.WriteString("# HELP ssgo_command_errors_total Failed SSDB commands by status.\n")
+	b.WriteString("# TYPE ssgo_command_errors_total counter\n")
+	for _, name := range names {
+		cm := m.cmds[name]
+		statuses := make([]string, 0, len(cm.errors))
+		for st := range cm.errors {
+			statuses = append(statuses, st)
+		}
+		sort.Strings(statuses)
+		for _, st := range statuses {
+			fmt.Fprintf(&b, "ssgo_command_errors_total{%s,%s} %d\n", label("cmd", name), label("status", st), cm.errors[st])
+		}
+	}
+	m.mu.Unlock()
+
+	b.WriteString("# HELP ssgo_sent_bytes_total Bytes written to SSDB.\n")
+	b.WriteString("# TYPE ssgo_sent_bytes_total counter\n")
+	fmt.Fprintf(&b, "ssgo_sent_bytes_total %d\n", m.bytesSent.Load())
+	b.WriteString("# HELP ssgo_received_bytes_total Bytes read from SSDB.\n")
+	b.WriteString("# TYPE ssgo_received_bytes_total counter\n")
+	fmt.Fprintf(&b, "ssgo_received_bytes_total %d\n", m.bytesRecv.Load())
+
+	stats := m.poolStats()
+	addrs := make([]string, 0, len(stats))
+	for addr := range stats {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	gauge := func(name, help, typ string, value func(st PoolStats) string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for _, addr := range addrs {
+			fmt.Fprintf(&b, "%s{%s} %s\n", name, label("addr", addr), value(stats[addr]))
+		}
+	}
+	itoa := func(n int64) string { return strconv.FormatInt(n, 10) }
+	gauge("ssgo_pool_idle_connections", "Open connections waiting in the pool.", "gauge",
+		func(st PoolStats) string { return itoa(int64(st.IdleConns)) })
+	gauge("ssgo_pool_in_use_connections", "Open connections handed out by the pool.", "gauge",
+		func(st PoolStats) string { return itoa(int64(st.InUse)) })
+	gauge("ssgo_pool_dials_total", "Connection attempts.", "counter",
+		func(st PoolStats) string { return itoa(st.Dials) })
+	gauge("ssgo_pool_dial_failures_total", "Failed connection attempts.", "counter",
+		func(st PoolStats) string { return itoa(st.DialFailures) })
+	gauge("ssgo_pool_waits_total", "Waits for a connection at MaxActive.", "counter",
+		func(st PoolStats) string { return itoa(st.Waits) })
+	gauge("ssgo_pool_wait_seconds_total", "Time spent waiting for a connection.", "counter",
+		func(st PoolStats) string { return formatFloat(st.WaitDuration.Seconds()) })
+	gauge("ssgo_pool_closed_errors_total", "Connections closed after an error.", "counter",
+		func(st PoolStats) string { return itoa(st.ClosedErrors) })
+	gauge("ssgo_pool_closed_stale_total", "Connections closed as stale.", "counter",
+		func(st PoolStats) string { return itoa(st.ClosedStale) })
+	gauge("ssgo_pool_retries_total", "Commands retried after a network error.", "counter",
+		func(st PoolStats) string { return itoa(st.Retries) })
+	gauge("ssgo_pool_breaker_trips_total", "Times the circuit breaker opened.", "counter",
+		func(st PoolStats) string { return itoa(st.BreakerTrips) })
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func label(name, value string) string {
+	return name + `="` + labelEscaper.Replace(value) + `"`
+}
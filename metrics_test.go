@@ -0,0 +1,67 @@
+package ssgo
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		if req[0] == "get" {
+			return []string{"not_found"}
+		}
+		return []string{"ok"}
+	})
+	m := NewMetrics()
+	p := NewConPool(srv.Addr(), 1, WithMetrics(m))
+
+	if _, err := p.Do("ping"); err != nil {
+		t.Fatal(err)
+	}
+	// "4\nping\n\n" out, "2\nok\n\n" back.
+	if sent, recv := m.bytesSent.Load(), m.bytesRecv.Load(); sent != 8 || recv != 6 {
+		t.Fatalf("counted %d bytes sent, %d received", sent, recv)
+	}
+	p.BatchDo(BatchExec{{"get", "k"}, {"ping"}})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		`ssgo_command_duration_seconds_count{cmd="ping"} 2`,
+		`ssgo_command_duration_seconds_bucket{cmd="get",le="+Inf"} 1`,
+		`ssgo_command_errors_total{cmd="get",status="not_found"} 1`,
+		`ssgo_pool_idle_connections{addr="` + srv.Addr() + `"} 1`,
+		`ssgo_pool_dials_total{addr="` + srv.Addr() + `"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in\n%s", line, body)
+		}
+	}
+
+	// What Publish exports, without claiming a global name per test run.
+	var v struct {
+		Commands map[string]struct{ Count int64 }
+		Pools    map[string]PoolStats
+	}
+	if err := json.Unmarshal([]byte(expvar.Func(m.expvar).String()), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Commands["ping"].Count != 2 || v.Pools[srv.Addr()].Commands != 3 {
+		t.Fatalf("expvar returned %+v", v)
+	}
+
+	p.Close()
+	if len(m.poolStats()) != 0 {
+		t.Fatal("closed pool still reported")
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	if got := label("cmd", "a\"b\\c\nd"); got != `cmd="a\"b\\c\nd"` {
+		t.Fatalf("label returned %s", got)
+	}
+}
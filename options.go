@@ -0,0 +1,232 @@
+package ssgo
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// DefaultDialTimeout bounds connecting when Options.DialTimeout is not set.
+const DefaultDialTimeout = 30 * time.Second
+
+// DefaultBatchChunkSize is the number of commands BatchDo writes to the
+// socket at once when Options.BatchChunkSize is not set.
+const DefaultBatchChunkSize = 128
+
+// Dialer opens the connection to addr, see Options.Dialer.
+type Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Options holds the settings shared by a Client and the ConPool it came from.
+type Options struct {
+	// Dialer replaces net.Dialer for opening connections, to go through a
+	// tunnel or an in-memory net.Pipe for instance. ctx carries DialTimeout.
+	Dialer Dialer
+
+	// TLSConfig, if set, wraps every connection in TLS. Unless it sets
+	// ServerName, the server certificate is checked against the dialed host.
+	TLSConfig *tls.Config
+	// TLSHandshakeTimeout bounds the TLS handshake. Zero leaves it to
+	// DialTimeout alone.
+	TLSHandshakeTimeout time.Duration
+
+	// Password is sent with auth on every new connection, if set.
+	Password string
+
+	// DialTimeout bounds connecting to the server, auth included.
+	DialTimeout time.Duration
+	// ReadTimeout bounds reading each reply. Zero means no timeout.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds writing each request. Zero means no timeout.
+	WriteTimeout time.Duration
+
+	// Interceptors wrap every command, the first being the outermost.
+	Interceptors []Interceptor
+
+	// Metrics, set by WithMetrics, counts the bytes sent and received and
+	// tracks the pools created with these options.
+	Metrics *Metrics
+
+	// Tracer, set by WithTracer, receives a span for every command, pool
+	// wait, send and recv.
+	Tracer Tracer
+
+	// Logger, if set, receives dial failures, discarded connections,
+	// protocol errors and, with SlowThreshold, slow commands.
+	Logger *slog.Logger
+	// SlowThreshold logs commands that take longer, at warning level.
+	SlowThreshold time.Duration
+
+	// Retry, set by WithRetry, retries the idempotent commands of a ConPool
+	// that fail on a network error.
+	Retry *RetryPolicy
+
+	// Breaker, set by WithBreaker, makes a ConPool fail fast while the
+	// server looks down.
+	Breaker *BreakerPolicy
+
+	// BatchChunkSize caps how many commands of a BatchExec are buffered and
+	// written in one go before their replies are read back.
+	BatchChunkSize int
+
+	// MaxActive caps the connections a ConPool keeps open, idle or in use.
+	// Zero means no limit.
+	MaxActive int
+	// WaitTimeout bounds how long GetClient waits for a connection once
+	// MaxActive is reached. Zero means wait until one is released.
+	WaitTimeout time.Duration
+
+	// IdleTimeout closes connections left idle in a ConPool for longer.
+	IdleTimeout time.Duration
+	// MaxLifetime closes pooled connections older than this.
+	MaxLifetime time.Duration
+	// TestOnBorrow pings idle connections before GetClient hands them out.
+	TestOnBorrow bool
+}
+
+// Option configures Options, see Connect and NewConPool.
+type Option func(*Options)
+
+// WithDialer sets Options.Dialer.
+func WithDialer(d Dialer) Option {
+	return func(o *Options) {
+		o.Dialer = d
+	}
+}
+
+// WithTLSConfig sets Options.TLSConfig.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(o *Options) {
+		o.TLSConfig = cfg
+	}
+}
+
+// WithTLSHandshakeTimeout sets Options.TLSHandshakeTimeout.
+func WithTLSHandshakeTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.TLSHandshakeTimeout = d
+	}
+}
+
+// WithPassword sets Options.Password.
+func WithPassword(password string) Option {
+	return func(o *Options) {
+		o.Password = password
+	}
+}
+
+// WithDialTimeout sets Options.DialTimeout.
+func WithDialTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.DialTimeout = d
+	}
+}
+
+// WithReadTimeout sets Options.ReadTimeout.
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.ReadTimeout = d
+	}
+}
+
+// WithWriteTimeout sets Options.WriteTimeout.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.WriteTimeout = d
+	}
+}
+
+// WithInterceptors appends to Options.Interceptors.
+func WithInterceptors(is ...Interceptor) Option {
+	return func(o *Options) {
+		o.Interceptors = append(o.Interceptors, is...)
+	}
+}
+
+// WithLogger sets Options.Logger.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithSlowThreshold sets Options.SlowThreshold.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *Options) {
+		o.SlowThreshold = d
+	}
+}
+
+// WithRetry sets Options.Retry.
+func WithRetry(p RetryPolicy) Option {
+	return func(o *Options) {
+		o.Retry = &p
+	}
+}
+
+// WithBreaker sets Options.Breaker.
+func WithBreaker(p BreakerPolicy) Option {
+	return func(o *Options) {
+		o.Breaker = &p
+	}
+}
+
+// WithBatchChunkSize sets Options.BatchChunkSize.
+func WithBatchChunkSize(n int) Option {
+	return func(o *Options) {
+		o.BatchChunkSize = n
+	}
+}
+
+// WithMaxActive sets Options.MaxActive.
+func WithMaxActive(n int) Option {
+	return func(o *Options) {
+		o.MaxActive = n
+	}
+}
+
+// WithWaitTimeout sets Options.WaitTimeout.
+func WithWaitTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.WaitTimeout = d
+	}
+}
+
+// WithIdleTimeout sets Options.IdleTimeout.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.IdleTimeout = d
+	}
+}
+
+// WithMaxLifetime sets Options.MaxLifetime.
+func WithMaxLifetime(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxLifetime = d
+	}
+}
+
+// WithTestOnBorrow sets Options.TestOnBorrow.
+func WithTestOnBorrow(test bool) Option {
+	return func(o *Options) {
+		o.TestOnBorrow = test
+	}
+}
+
+func newOptions(opts []Option) *Options {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = DefaultDialTimeout
+	}
+	if o.BatchChunkSize < 1 {
+		o.BatchChunkSize = DefaultBatchChunkSize
+	}
+	if o.Logger != nil && o.SlowThreshold > 0 {
+		o.Interceptors = append(o.Interceptors, o.logSlow)
+	}
+	return o
+}
@@ -2,51 +2,176 @@ package ssgo
 
 import (
 	"bufio"
+	"container/list"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
 	"net"
 	"runtime"
+	"strings"
+	"sync"
 	"time"
 )
 
+var (
+	// ErrPoolExhausted is returned when Options.MaxActive connections are
+	// in use and none was released within Options.WaitTimeout, or at once
+	// by TryGetClient.
+	ErrPoolExhausted = errors.New("ssgo: connection pool exhausted")
+)
+
 type ConPool struct {
-	cType    string
-	cAddr    string
-	cTimeout time.Duration
-	conns    chan *Client
+	commands
+
+	cType string
+	cAddr string
+	conns chan *Client
+	opts  *Options
+
+	mu      sync.Mutex
+	active  int       // open connections, idle ones included
+	waiters list.List // of *waiter, oldest first
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	stats   poolCounters
+	breaker *breaker // nil unless Options.Breaker is set
+}
+
+// waiter is a GetClient call blocked on a full pool.
+type waiter struct {
+	ch     chan *Client
+	served bool
 }
 
-func NewConPool(hostAddr string, maxConn int) *ConPool {
+func NewConPool(hostAddr string, maxConn int, opts ...Option) *ConPool {
 
 	if maxConn < 1 {
 		maxConn = runtime.NumCPU() * 2
 	}
 
+	o := newOptions(opts)
+	network, addr := splitAddr(hostAddr)
 	cr := &ConPool{
-		cType:    "tcp",
-		cAddr:    hostAddr,
-		cTimeout: time.Duration(30) * time.Second,
-		conns:    make(chan *Client, maxConn),
+		cType:  network,
+		cAddr:  addr,
+		conns:  make(chan *Client, maxConn),
+		opts:   o,
+		closed: make(chan struct{}),
+	}
+	cr.commands.call = cr.Do
+	if o.Breaker != nil {
+		cr.breaker = newBreaker(*o.Breaker)
+	}
+	if o.Metrics != nil {
+		o.Metrics.addPool(cr)
 	}
 
+	if interval := cr.reapInterval(); interval > 0 {
+		go cr.reaper(interval)
+	}
 	return cr
 }
 
-func dialTimeout(network, addr string) (*Client, error) {
+// splitAddr splits a "unix:///path/to/ssdb.sock" or "tcp://host:port"
+// address into its network and address parts. Plain "host:port" is TCP.
+func splitAddr(hostAddr string) (network, addr string) {
+	for _, network := range []string{"unix", "tcp"} {
+		if strings.HasPrefix(hostAddr, network+"://") {
+			return network, hostAddr[len(network)+3:]
+		}
+	}
+	return "tcp", hostAddr
+}
+
+// dial connects to addr and prepares the connection as o asks, issuing
+// auth if o.Password is set. Both steps are bounded by ctx and
+// Options.DialTimeout.
+func dial(ctx context.Context, network, addr string, o *Options) (*Client, error) {
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, o.DialTimeout)
+	defer cancel()
+	// callerErr reports the caller's context error rather than err when it
+	// is the cause, so that it does not pass for a network failure.
+	callerErr := func(err error) error {
+		if ctxErr := contextErr(parent); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
 
-	rAddr, err := net.ResolveTCPAddr(network, addr)
-	if err != nil {
-		return nil, err
+	dialer := o.Dialer
+	if dialer == nil {
+		dialer = new(net.Dialer).DialContext
 	}
-	sock, err := net.DialTCP(network, nil, rAddr)
+	sock, err := dialer(ctx, network, addr)
 	if err != nil {
+		err = callerErr(wrapTimeout("dial", err))
+		o.log(slog.LevelWarn, "ssgo: dial failed", "network", network, "addr", addr, "err", err)
 		return nil, err
 	}
+	if o.TLSConfig != nil {
+		if sock, err = handshake(ctx, sock, addr, o); err != nil {
+			err = callerErr(err)
+			o.log(slog.LevelWarn, "ssgo: tls handshake failed", "network", network, "addr", addr, "err", err)
+			return nil, err
+		}
+	}
 
-	return &Client{sock: sock, reader: bufio.NewReader(sock)}, nil
+	c := &Client{sock: sock, reader: bufio.NewReader(sock), opts: o}
+	c.commands.call = c.Do
+	c.created = time.Now()
+
+	if o.Password != "" {
+		// straight to the server, so the password never reaches interceptors
+		if _, err := c.invoke(ctx, []interface{}{"auth", o.Password}); err != nil {
+			err = callerErr(err)
+			o.log(slog.LevelWarn, "ssgo: auth failed", "network", network, "addr", addr, "err", err)
+			c.close()
+			var se *StatusError
+			if errors.As(err, &se) {
+				return nil, &AuthError{Err: err}
+			}
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// handshake wraps sock in TLS as o.TLSConfig asks. Unless the config names
+// the server, the certificate is checked against the host part of addr.
+func handshake(ctx context.Context, sock net.Conn, addr string, o *Options) (net.Conn, error) {
+	cfg := o.TLSConfig
+	if cfg.ServerName == "" {
+		cfg = cfg.Clone()
+		cfg.ServerName = addr
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			cfg.ServerName = host
+		}
+	}
+	if o.TLSHandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.TLSHandshakeTimeout)
+		defer cancel()
+	}
+	conn := tls.Client(sock, cfg)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		sock.Close()
+		return nil, wrapTimeout("tls handshake", err)
+	}
+	return conn, nil
 }
 
-func (cr *ConPool) dialNew() (*Client, error) {
-	cn, err := dialTimeout(cr.cType, cr.cAddr)
+func (cr *ConPool) dialNew(ctx context.Context) (*Client, error) {
+	cr.stats.dials.Add(1)
+	cn, err := dial(ctx, cr.cType, cr.cAddr, cr.opts)
 	if err != nil {
+		// only failures count: a server may accept connections and then
+		// never answer, successes are left to the commands
+		cr.observe(err)
+		cr.stats.dialFailures.Add(1)
 		return nil, err
 	}
 	cn.pool = cr
@@ -54,51 +179,294 @@ func (cr *ConPool) dialNew() (*Client, error) {
 }
 
 func (cr *ConPool) Do(args ...interface{}) (Reply, error) {
+	return cr.DoContext(context.Background(), args...)
+}
 
-	cn, e := cr.GetClient()
-	if e != nil {
-		return nil, e
-	}
-	defer cn.Release()
+func (cr *ConPool) BatchDo(batch BatchExec) ([]ReplyE, error) {
+	return cr.BatchDoContext(context.Background(), batch)
+}
 
-	return cn.Do(args...)
+// DoContext runs a single command on a pooled connection, bounded by ctx.
+func (cr *ConPool) DoContext(ctx context.Context, args ...interface{}) (rep Reply, err error) {
+	err = cr.retry(ctx, IsIdempotent(cmdName(args)), func(cn *Client) error {
+		rep, err = cn.DoContext(ctx, args...)
+		return err
+	})
+	return rep, err
 }
 
-func (cr *ConPool) BatchDo(batch BatchExec) ([]ReplyE, error) {
-	cn, e := cr.GetClient()
-	if e != nil {
+// BatchDoContext runs batch on a single pooled connection, bounded by ctx.
+func (cr *ConPool) BatchDoContext(ctx context.Context, batch BatchExec) (reps []ReplyE, err error) {
+	var connErr error
+	e := cr.retry(ctx, batchIdempotent(batch), func(cn *Client) error {
+		reps, err = cn.BatchDoContext(ctx, batch)
+		connErr = cn.err
+		return connErr
+	})
+	if e != connErr {
+		// no connection for the last attempt
 		return nil, e
 	}
-	defer cn.Release()
-
-	return cn.BatchDo(batch)
+	return reps, err
 }
 
 func (cr *ConPool) Close() {
+	cr.closeOnce.Do(func() {
+		close(cr.closed)
+		if cr.opts.Metrics != nil {
+			cr.opts.Metrics.removePool(cr)
+		}
+	})
 	var conn *Client
 	for {
 		select {
 		case conn = <-cr.conns:
-			conn.close()
+			cr.discard(conn)
 		default:
 			return
 		}
 	}
 }
 
+// push takes back a released connection.
 func (cr *ConPool) push(cn *Client) {
+	cn.lastUsed = time.Now()
+	if cr.stale(cn, cn.lastUsed) {
+		cr.stats.closedStale.Add(1)
+		cr.discard(cn)
+		return
+	}
+	cr.park(cn)
+}
+
+// park hands cn to the oldest waiter, or puts it in the idle list.
+func (cr *ConPool) park(cn *Client) {
+	cr.mu.Lock()
+	if w := cr.popWaiter(); w != nil {
+		cr.mu.Unlock()
+		w.ch <- cn
+		return
+	}
 	select {
 	case cr.conns <- cn:
+		cr.mu.Unlock()
 	default:
-		cn.close()
+		cr.mu.Unlock()
+		cr.discard(cn)
 	}
 }
 
+// discard closes cn and frees its slot.
+func (cr *ConPool) discard(cn *Client) error {
+	err := cn.close()
+	if err != nil {
+		cr.opts.log(slog.LevelWarn, "ssgo: close failed", "addr", cr.cAddr, "err", err)
+	}
+	cr.freeSlot()
+	return err
+}
+
+// freeSlot gives up one slot of active. If someone is waiting the slot
+// passes to them instead, signalled by a nil client.
+func (cr *ConPool) freeSlot() {
+	cr.mu.Lock()
+	if w := cr.popWaiter(); w != nil {
+		cr.mu.Unlock()
+		w.ch <- nil
+		return
+	}
+	cr.active--
+	cr.mu.Unlock()
+}
+
+// popWaiter dequeues the oldest waiter. cr.mu must be held.
+func (cr *ConPool) popWaiter() *waiter {
+	e := cr.waiters.Front()
+	if e == nil {
+		return nil
+	}
+	w := cr.waiters.Remove(e).(*waiter)
+	w.served = true
+	return w
+}
+
 func (cr *ConPool) GetClient() (cn *Client, err error) {
+	return cr.GetClientContext(context.Background())
+}
+
+// TryGetClient is like GetClient, but fails with ErrPoolExhausted instead of
+// waiting when Options.MaxActive connections are in use.
+func (cr *ConPool) TryGetClient() (*Client, error) {
+	return cr.getClient(context.Background(), false, false)
+}
+
+// GetClientContext is like GetClient, but gives up waiting for a free
+// connection once ctx is done.
+func (cr *ConPool) GetClientContext(ctx context.Context) (*Client, error) {
+	return cr.getClient(ctx, true, false)
+}
+
+// getClient takes an idle connection or dials one, waiting for a free slot
+// if wait is set. With fresh, idle connections are closed instead and their
+// slot redialed.
+func (cr *ConPool) getClient(ctx context.Context, wait, fresh bool) (*Client, error) {
+	if err := cr.allow(ctx); err != nil {
+		return nil, err
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cr.mu.Lock()
+		select {
+		case conn := <-cr.conns:
+			cr.mu.Unlock()
+			if fresh {
+				cr.stats.closedStale.Add(1)
+				conn.close()
+				return cr.dialSlot(ctx)
+			}
+			if cr.usable(ctx, conn) {
+				return conn, nil
+			}
+			continue
+		default:
+		}
+		if cr.opts.MaxActive <= 0 || cr.active < cr.opts.MaxActive {
+			cr.active++
+			cr.mu.Unlock()
+			return cr.dialSlot(ctx)
+		}
+		if !wait {
+			cr.mu.Unlock()
+			return nil, ErrPoolExhausted
+		}
+		w := &waiter{ch: make(chan *Client, 1)}
+		e := cr.waiters.PushBack(w)
+		cr.mu.Unlock()
+		return cr.wait(ctx, w, e)
+	}
+}
+
+// wait blocks until w is served, ctx is done or Options.WaitTimeout passes.
+// Connections handed over by push come straight from Release, so unlike
+// idle ones they are not checked again.
+func (cr *ConPool) wait(ctx context.Context, w *waiter, e *list.Element) (cn *Client, err error) {
+	cr.stats.waits.Add(1)
+	_, span := startSpan(ctx, cr.opts, SpanInfo{Op: SpanPoolWait})
+	defer func(start time.Time) {
+		cr.stats.waitNanos.Add(int64(time.Since(start)))
+		span.End(err, 0)
+	}(time.Now())
+
+	var timeout <-chan time.Time
+	if cr.opts.WaitTimeout > 0 {
+		t := time.NewTimer(cr.opts.WaitTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+
 	select {
-	case conn := <-cr.conns:
+	case conn := <-w.ch:
+		return cr.takeHandoff(ctx, conn)
+	case <-timeout:
+		err = ErrPoolExhausted
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	cr.mu.Lock()
+	if !w.served {
+		cr.waiters.Remove(e)
+		cr.mu.Unlock()
+		return nil, err
+	}
+	cr.mu.Unlock()
+	// We were served while giving up; the handoff is ours to keep.
+	return cr.takeHandoff(ctx, <-w.ch)
+}
+
+// stale reports whether cn outlived Options.IdleTimeout or
+// Options.MaxLifetime at now.
+func (cr *ConPool) stale(cn *Client, now time.Time) bool {
+	if d := cr.opts.IdleTimeout; d > 0 && now.Sub(cn.lastUsed) > d {
+		return true
+	}
+	if d := cr.opts.MaxLifetime; d > 0 && now.Sub(cn.created) > d {
+		return true
+	}
+	return false
+}
+
+// usable checks an idle connection before it is handed out, and discards
+// it if it is stale or fails Options.TestOnBorrow. ctx bounds the ping.
+func (cr *ConPool) usable(ctx context.Context, cn *Client) bool {
+	ok := !cr.stale(cn, time.Now())
+	if ok && cr.opts.TestOnBorrow {
+		_, err := cn.invoke(ctx, []interface{}{"ping"})
+		ok = err == nil
+	}
+	if !ok {
+		cr.stats.closedStale.Add(1)
+		cr.discard(cn)
+	}
+	return ok
+}
+
+func (cr *ConPool) reapInterval() time.Duration {
+	d := cr.opts.IdleTimeout
+	if l := cr.opts.MaxLifetime; l > 0 && (d <= 0 || l < d) {
+		d = l
+	}
+	return d / 2
+}
+
+// reaper closes stale idle connections until the pool is closed.
+func (cr *ConPool) reaper(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-cr.closed:
+			return
+		case <-t.C:
+			cr.reap()
+		}
+	}
+}
+
+func (cr *ConPool) reap() {
+	now := time.Now()
+	for n := len(cr.conns); n > 0; n-- {
+		select {
+		case cn := <-cr.conns:
+			if cr.stale(cn, now) {
+				cr.stats.closedStale.Add(1)
+				cr.discard(cn)
+			} else {
+				cr.park(cn)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// takeHandoff accepts what push or discard gave a waiter: either a ready
+// connection, or nil for a free slot that still has to be dialed.
+func (cr *ConPool) takeHandoff(ctx context.Context, conn *Client) (*Client, error) {
+	if conn != nil {
 		return conn, nil
-	default:
-		return cr.dialNew()
 	}
+	return cr.dialSlot(ctx)
+}
+
+// dialSlot dials a connection for a slot already counted in active.
+func (cr *ConPool) dialSlot(ctx context.Context) (*Client, error) {
+	cn, err := cr.dialNew(ctx)
+	if err != nil {
+		cr.freeSlot()
+		return nil, err
+	}
+	return cn, nil
 }
@@ -1,7 +1,19 @@
 package ssgo
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
 	"testing"
+	"time"
 )
 
 func pingBenchmark(t *testing.T, poolCache, parallel, times int) {
@@ -34,3 +46,272 @@ func TestConnPool(t *testing.T) {
 	pingBenchmark(t, 3, 10, 1000)
 	pingBenchmark(t, 3, 100, 1000)
 }
+
+func okServer(t *testing.T) *testServer {
+	return newTestServer(t, func(req []string) []string {
+		return []string{"ok"}
+	})
+}
+
+func TestConnPoolMaxActive(t *testing.T) {
+	srv := okServer(t)
+	p := NewConPool(srv.Addr(), 2, WithMaxActive(2), WithWaitTimeout(50*time.Millisecond))
+	defer p.Close()
+
+	c1, err := p.GetClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := p.GetClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.TryGetClient(); err != ErrPoolExhausted {
+		t.Fatalf("TryGetClient returned %v, want %v", err, ErrPoolExhausted)
+	}
+	start := time.Now()
+	if _, err := p.GetClient(); err != ErrPoolExhausted {
+		t.Fatalf("GetClient returned %v, want %v", err, ErrPoolExhausted)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("GetClient gave up after %v", d)
+	}
+
+	// Waiters are served in arrival order, by a released connection or by
+	// the slot of a broken one.
+	got := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func(i int) {
+			cn, err := p.GetClientContext(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got <- i
+			cn.Release()
+		}(i)
+		time.Sleep(10 * time.Millisecond)
+	}
+	c1.Release()
+	if i := <-got; i != 0 {
+		t.Fatalf("waiter %d served first", i)
+	}
+	c2.err = io.EOF
+	c2.Release()
+	if i := <-got; i != 1 {
+		t.Fatalf("waiter %d served second", i)
+	}
+	if p.active > 2 {
+		t.Fatalf("%d connections open", p.active)
+	}
+}
+
+func TestConnPoolWaitContext(t *testing.T) {
+	srv := okServer(t)
+	p := NewConPool(srv.Addr(), 1, WithMaxActive(1))
+	defer p.Close()
+
+	cn, err := p.GetClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cn.Release()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.DoContext(ctx, "ping"); err != context.DeadlineExceeded {
+		t.Fatalf("DoContext returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if p.waiters.Len() != 0 {
+		t.Fatal("expired waiter left in the queue")
+	}
+}
+
+func TestConnPoolIdleTimeout(t *testing.T) {
+	srv := okServer(t)
+	p := NewConPool(srv.Addr(), 2, WithIdleTimeout(20*time.Millisecond))
+	defer p.Close()
+
+	cn, err := p.GetClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cn.Release()
+	if len(p.conns) != 1 {
+		t.Fatal("connection not returned to the pool")
+	}
+	time.Sleep(60 * time.Millisecond)
+	p.mu.Lock()
+	active := p.active
+	p.mu.Unlock()
+	if len(p.conns) != 0 || active != 0 {
+		t.Fatalf("idle connection not reaped: %d idle, %d active", len(p.conns), active)
+	}
+}
+
+func TestConnPoolTestOnBorrow(t *testing.T) {
+	srv := okServer(t)
+	p := NewConPool(srv.Addr(), 2, WithTestOnBorrow(true))
+	defer p.Close()
+
+	cn, err := p.GetClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cn.Release()
+	srv.CloseConns()
+
+	if _, err := p.Do("get", "k"); err != nil {
+		t.Fatalf("Do after server restart: %v", err)
+	}
+	if p.active != 1 {
+		t.Fatalf("%d connections open, want 1", p.active)
+	}
+}
+
+func TestConnPoolTestOnBorrowContext(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		if req[0] == "ping" {
+			return nil // stall
+		}
+		return []string{"ok"}
+	})
+	p := NewConPool(srv.Addr(), 2, WithTestOnBorrow(true))
+	defer p.Close()
+
+	if _, err := p.Do("get", "k"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := p.DoContext(ctx, "get", "k"); err != context.DeadlineExceeded {
+		t.Fatalf("DoContext returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("DoContext took %v", d)
+	}
+}
+
+// newTLSTestServer runs an SSDB stand-in behind TLS, with a self-signed
+// certificate for 127.0.0.1 that the returned pool trusts.
+func newTLSTestServer(t *testing.T) (*testServer, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ssgo test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln = tls.NewListener(ln, &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	srv := &testServer{ln: ln, handle: func(req []string) []string {
+		return []string{"ok", "pong"}
+	}}
+	go srv.serve()
+	t.Cleanup(func() { ln.Close() })
+	return srv, roots
+}
+
+func TestConnPoolTLS(t *testing.T) {
+	srv, roots := newTLSTestServer(t)
+
+	p := NewConPool(srv.Addr(), 1, WithTLSConfig(&tls.Config{RootCAs: roots}))
+	defer p.Close()
+	if r, err := p.Do("ping"); err != nil || r.String() != "pong" {
+		t.Fatalf("Do returned %v, %v", r, err)
+	}
+
+	bad := NewConPool(srv.Addr(), 1, WithTLSConfig(&tls.Config{RootCAs: roots, ServerName: "ssdb.example.com"}))
+	defer bad.Close()
+	var certErr *tls.CertificateVerificationError
+	if _, err := bad.Do("ping"); !errors.As(err, &certErr) {
+		t.Fatalf("Do returned %v, want a certificate error", err)
+	}
+}
+
+func TestConnPoolTLSHandshakeTimeout(t *testing.T) {
+	// A listener nobody serves never answers the client hello.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	p := NewConPool(ln.Addr().String(), 1,
+		WithTLSConfig(&tls.Config{InsecureSkipVerify: true}),
+		WithTLSHandshakeTimeout(30*time.Millisecond))
+	defer p.Close()
+	if _, err := p.Do("ping"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Do returned %v, want %v", err, ErrTimeout)
+	}
+}
+
+func TestConnPoolStats(t *testing.T) {
+	srv := okServer(t)
+	p := NewConPool(srv.Addr(), 2, WithMaxActive(1), WithWaitTimeout(10*time.Millisecond))
+	defer p.Close()
+
+	cn, err := p.GetClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cn.BatchDo(BatchExec{{"ping"}, {"ping"}})
+	if _, err := p.GetClient(); err != ErrPoolExhausted {
+		t.Fatalf("GetClient returned %v, want %v", err, ErrPoolExhausted)
+	}
+	st := p.Stats()
+	if st.TotalConns != 1 || st.InUse != 1 || st.IdleConns != 0 || st.Waits != 1 || st.WaitDuration < 10*time.Millisecond {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+	cn.err = io.EOF
+	cn.Release()
+
+	bad := NewConPool("127.0.0.1:1", 1)
+	bad.Do("ping")
+	if st := bad.Stats(); st.Dials != 1 || st.DialFailures != 1 || st.TotalConns != 0 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+
+	p.Do("ping")
+	st = p.Stats()
+	want := PoolStats{TotalConns: 1, IdleConns: 1, Dials: 2, Waits: 1, WaitDuration: st.WaitDuration, ClosedErrors: 1, Commands: 3}
+	if st != want {
+		t.Fatalf("Stats returned %+v, want %+v", st, want)
+	}
+
+	// auth and the test-on-borrow ping are not user commands
+	authSrv := newAuthTestServer(t, "s3cret", func(req []string) []string {
+		return []string{"ok"}
+	})
+	ap := NewConPool(authSrv.Addr(), 1, WithPassword("s3cret"), WithTestOnBorrow(true))
+	defer ap.Close()
+	for i := 0; i < 2; i++ {
+		if _, err := ap.Do("get", "k"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st := ap.Stats(); st.Commands != 2 {
+		t.Fatalf("counted %d commands, want 2", st.Commands)
+	}
+}
@@ -0,0 +1,119 @@
+package ssgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEmptyQueue is wrapped by the errors of QPopFront, QPopBack, QFront and
+// QBack when the queue holds nothing.
+var ErrEmptyQueue = errors.New("ssgo: queue is empty")
+
+// replyQueue decodes the items taken from the ends of a queue.
+func replyQueue(cmd string, r Reply, err error) ([]string, error) {
+	if errors.Is(err, ErrNotFound) || (err == nil && len(r) == 0) {
+		return nil, fmt.Errorf("%s: %w", cmd, ErrEmptyQueue)
+	}
+	return replyList(r, err)
+}
+
+// QPushFront adds vals to the front of queue name, and returns its new size.
+func (c commands) QPushFront(name string, vals ...interface{}) (int64, error) {
+	r, err := c.call(append([]interface{}{"qpush_front", name}, vals...)...)
+	return replyInt64("qpush_front", r, err)
+}
+
+// QPushBack adds vals to the back of queue name, and returns its new size.
+func (c commands) QPushBack(name string, vals ...interface{}) (int64, error) {
+	r, err := c.call(append([]interface{}{"qpush_back", name}, vals...)...)
+	return replyInt64("qpush_back", r, err)
+}
+
+// QPopFront removes and returns up to count items from the front of queue
+// name.
+func (c commands) QPopFront(name string, count int) ([]string, error) {
+	r, err := c.call("qpop_front", name, count)
+	return replyQueue("qpop_front", r, err)
+}
+
+// QPopBack removes and returns up to count items from the back of queue
+// name.
+func (c commands) QPopBack(name string, count int) ([]string, error) {
+	r, err := c.call("qpop_back", name, count)
+	return replyQueue("qpop_back", r, err)
+}
+
+// QFront returns the first item of queue name.
+func (c commands) QFront(name string) (string, error) {
+	r, err := c.call("qfront", name)
+	vals, err := replyQueue("qfront", r, err)
+	return replyString("qfront", vals, err)
+}
+
+// QBack returns the last item of queue name.
+func (c commands) QBack(name string) (string, error) {
+	r, err := c.call("qback", name)
+	vals, err := replyQueue("qback", r, err)
+	return replyString("qback", vals, err)
+}
+
+// QSize returns the number of items in queue name.
+func (c commands) QSize(name string) (int64, error) {
+	r, err := c.call("qsize", name)
+	return replyInt64("qsize", r, err)
+}
+
+// QGet returns the item at index of queue name. A negative index counts
+// from the back. The error matches ErrNotFound if index is out of range.
+func (c commands) QGet(name string, index int64) (string, error) {
+	r, err := c.call("qget", name, index)
+	return replyString("qget", r, err)
+}
+
+// QSet replaces the item at index of queue name.
+func (c commands) QSet(name string, index int64, val interface{}) error {
+	_, err := c.call("qset", name, index, val)
+	return err
+}
+
+// QSlice returns the items of queue name from begin to end, both included.
+// Negative positions count from the back.
+func (c commands) QSlice(name string, begin, end int64) ([]string, error) {
+	return replyList(c.call("qslice", name, begin, end))
+}
+
+// QRange returns up to limit items of queue name from offset.
+func (c commands) QRange(name string, offset, limit int) ([]string, error) {
+	return replyList(c.call("qrange", name, offset, limit))
+}
+
+// QTrimFront removes up to size items from the front of queue name, and
+// returns how many were removed.
+func (c commands) QTrimFront(name string, size int) (int64, error) {
+	r, err := c.call("qtrim_front", name, size)
+	return replyInt64("qtrim_front", r, err)
+}
+
+// QTrimBack removes up to size items from the back of queue name, and
+// returns how many were removed.
+func (c commands) QTrimBack(name string, size int) (int64, error) {
+	r, err := c.call("qtrim_back", name, size)
+	return replyInt64("qtrim_back", r, err)
+}
+
+// QClear deletes queue name and returns the number of items it had.
+func (c commands) QClear(name string) (int64, error) {
+	r, err := c.call("qclear", name)
+	return replyInt64("qclear", r, err)
+}
+
+// QList returns up to limit queue names in (start, end], in ascending
+// order.
+func (c commands) QList(start, end string, limit int) ([]string, error) {
+	return replyList(c.call("qlist", start, end, limit))
+}
+
+// QRList is like QList, in descending order.
+func (c commands) QRList(start, end string, limit int) ([]string, error) {
+	return replyList(c.call("qrlist", start, end, limit))
+}
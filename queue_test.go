@@ -0,0 +1,54 @@
+package ssgo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQueueCommands(t *testing.T) {
+	var last []string
+	srv := newTestServer(t, func(req []string) []string {
+		last = req
+		switch req[0] {
+		case "qpush_back":
+			return []string{"ok", "3"}
+		case "qpop_front":
+			return []string{"ok", "a", "b"}
+		case "qpop_back":
+			return []string{"ok"}
+		case "qfront":
+			return []string{"not_found"}
+		case "qback":
+			return []string{"ok", "c"}
+		case "qget":
+			return []string{"not_found"}
+		}
+		return []string{"ok", "1"}
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	if n, err := p.QPushBack("q", "a", "b", 3); n != 3 || err != nil {
+		t.Fatalf("QPushBack returned %v, %v", n, err)
+	}
+	if !reflect.DeepEqual(last, []string{"qpush_back", "q", "a", "b", "3"}) {
+		t.Fatalf("sent %q", last)
+	}
+	vals, err := p.QPopFront("q", 2)
+	if err != nil || !reflect.DeepEqual(vals, []string{"a", "b"}) {
+		t.Fatalf("QPopFront returned %v, %v", vals, err)
+	}
+	if _, err := p.QPopBack("q", 2); !errors.Is(err, ErrEmptyQueue) {
+		t.Fatalf("QPopBack returned %v, want %v", err, ErrEmptyQueue)
+	}
+	if _, err := p.QFront("q"); !errors.Is(err, ErrEmptyQueue) {
+		t.Fatalf("QFront returned %v, want %v", err, ErrEmptyQueue)
+	}
+	if v, err := p.QBack("q"); v != "c" || err != nil {
+		t.Fatalf("QBack returned %v, %v", v, err)
+	}
+	if _, err := p.QGet("q", 10); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("QGet returned %v, want %v", err, ErrNotFound)
+	}
+}
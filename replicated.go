@@ -0,0 +1,170 @@
+package ssgo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// Balancer picks the slave a read goes to, among at least one.
+type Balancer func(slaves []*ConPool) *ConPool
+
+// RoundRobin returns a Balancer cycling through the slaves.
+func RoundRobin() Balancer {
+	var next atomic.Uint64
+	return func(slaves []*ConPool) *ConPool {
+		return slaves[(next.Add(1)-1)%uint64(len(slaves))]
+	}
+}
+
+// RandomBalancer picks a slave at random.
+func RandomBalancer(slaves []*ConPool) *ConPool {
+	return slaves[rand.Intn(len(slaves))]
+}
+
+// LeastInUse picks the slave with the fewest connections in use.
+func LeastInUse(slaves []*ConPool) *ConPool {
+	best, min := slaves[0], -1
+	for _, s := range slaves {
+		if n := s.Stats().InUse; min < 0 || n < min {
+			best, min = s, n
+		}
+	}
+	return best
+}
+
+// ReplicatedPool sends the read-only commands, see IsReadOnly, to the slaves
+// of an SSDB master and everything else to the master. Use ForceMaster, with
+// DoContext or WithContext, to read from the master, right after a write
+// for instance.
+type ReplicatedPool struct {
+	commands
+
+	master   *ConPool
+	slaves   []*ConPool
+	balancer Balancer
+}
+
+// NewReplicatedPool routes commands between master and slaves. A nil
+// balancer defaults to RoundRobin. Without slaves, everything goes to the
+// master.
+func NewReplicatedPool(master *ConPool, slaves []*ConPool, balancer Balancer) *ReplicatedPool {
+	if balancer == nil {
+		balancer = RoundRobin()
+	}
+	rp := &ReplicatedPool{master: master, slaves: slaves, balancer: balancer}
+	rp.commands.call = rp.Do
+	return rp
+}
+
+type forceMasterKey struct{}
+
+// ForceMaster returns a context making ReplicatedPool send reads to the
+// master too.
+func ForceMaster(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceMasterKey{}, true)
+}
+
+// WithContext returns a copy of rp whose typed commands, like HGet or
+// ZRange, run with ctx. Pass a ForceMaster context to read from the master:
+//
+//	v, err := rp.WithContext(ssgo.ForceMaster(ctx)).HGet("h", "f")
+func (rp *ReplicatedPool) WithContext(ctx context.Context) *ReplicatedPool {
+	rc := *rp
+	rc.commands.call = func(args ...interface{}) (Reply, error) {
+		return rp.DoContext(ctx, args...)
+	}
+	return &rc
+}
+
+// Master returns the pool of the master.
+func (rp *ReplicatedPool) Master() *ConPool {
+	return rp.master
+}
+
+// Slaves returns the pools of the slaves.
+func (rp *ReplicatedPool) Slaves() []*ConPool {
+	return rp.slaves
+}
+
+// pool picks the pool for commands that are all read-only or not.
+func (rp *ReplicatedPool) pool(ctx context.Context, read bool) *ConPool {
+	if !read || len(rp.slaves) == 0 || ctx.Value(forceMasterKey{}) != nil {
+		return rp.master
+	}
+	return rp.balancer(rp.slaves)
+}
+
+func (rp *ReplicatedPool) Do(args ...interface{}) (Reply, error) {
+	return rp.DoContext(context.Background(), args...)
+}
+
+func (rp *ReplicatedPool) BatchDo(batch BatchExec) ([]ReplyE, error) {
+	return rp.BatchDoContext(context.Background(), batch)
+}
+
+// DoContext runs a single command on the master or a slave, bounded by ctx.
+func (rp *ReplicatedPool) DoContext(ctx context.Context, args ...interface{}) (Reply, error) {
+	return rp.pool(ctx, IsReadOnly(cmdName(args))).DoContext(ctx, args...)
+}
+
+// BatchDoContext runs the reads of batch on a slave and the writes on the
+// master, concurrently, and returns the replies in the order of batch.
+// Reads do not see the writes of the same batch; use ForceMaster for that.
+func (rp *ReplicatedPool) BatchDoContext(ctx context.Context, batch BatchExec) ([]ReplyE, error) {
+	var reads, writes BatchExec
+	var readIdx, writeIdx []int
+	for i, cmd := range batch {
+		if IsReadOnly(cmdName(cmd)) {
+			reads, readIdx = append(reads, cmd), append(readIdx, i)
+		} else {
+			writes, writeIdx = append(writes, cmd), append(writeIdx, i)
+		}
+	}
+	if len(writes) == 0 || len(reads) == 0 {
+		return rp.pool(ctx, len(writes) == 0).BatchDoContext(ctx, batch)
+	}
+	slave := rp.pool(ctx, true)
+	if slave == rp.master {
+		return slave.BatchDoContext(ctx, batch)
+	}
+
+	reps := make([]ReplyE, len(batch))
+	var wg sync.WaitGroup
+	run := func(cr *ConPool, sub BatchExec, idx []int) {
+		defer wg.Done()
+		subReps, err := cr.BatchDoContext(ctx, sub)
+		for j, i := range idx {
+			if subReps == nil {
+				reps[i].E = err
+			} else {
+				reps[i] = subReps[j]
+			}
+		}
+	}
+	wg.Add(2)
+	go run(rp.master, writes, writeIdx)
+	go run(slave, reads, readIdx)
+	wg.Wait()
+
+	errCount := 0
+	for _, r := range reps {
+		if r.E != nil {
+			errCount++
+		}
+	}
+	if errCount != 0 {
+		return reps, fmt.Errorf("BatchDo: get %d errors", errCount)
+	}
+	return reps, nil
+}
+
+// Close closes the master and slave pools.
+func (rp *ReplicatedPool) Close() {
+	rp.master.Close()
+	for _, s := range rp.slaves {
+		s.Close()
+	}
+}
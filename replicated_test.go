@@ -0,0 +1,78 @@
+package ssgo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// namedServer replies to every command with its own name.
+func namedServer(t *testing.T, name string) *testServer {
+	return newTestServer(t, func(req []string) []string {
+		return []string{"ok", name}
+	})
+}
+
+func TestReplicatedPool(t *testing.T) {
+	master := NewConPool(namedServer(t, "master").Addr(), 2)
+	s1 := NewConPool(namedServer(t, "s1").Addr(), 2)
+	s2 := NewConPool(namedServer(t, "s2").Addr(), 2)
+	rp := NewReplicatedPool(master, []*ConPool{s1, s2}, nil)
+	defer rp.Close()
+
+	var got []string
+	for _, cmd := range [][]interface{}{{"get", "a"}, {"hgetall", "h"}, {"set", "a", 1}, {"zrange", "z", 0, 10}, {"incr", "a", 1}} {
+		r, err := rp.Do(cmd...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r.String())
+	}
+	if want := []string{"s1", "s2", "master", "s1", "master"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("routed to %q, want %q", got, want)
+	}
+	if r, err := rp.DoContext(ForceMaster(context.Background()), "get", "a"); err != nil || r.String() != "master" {
+		t.Fatalf("forced read returned %v, %v", r, err)
+	}
+	if v, err := rp.HGet("h", "f"); err != nil || v != "s2" {
+		t.Fatalf("HGet returned %q, %v", v, err)
+	}
+	if v, err := rp.WithContext(ForceMaster(context.Background())).HGet("h", "f"); err != nil || v != "master" {
+		t.Fatalf("forced HGet returned %q, %v", v, err)
+	}
+	if v, err := rp.Get("a"); err != nil || v != "s1" {
+		t.Fatalf("Get returned %q, %v", v, err)
+	}
+
+	reps, err := rp.BatchDo(BatchExec{{"get", "a"}, {"set", "a", 1}, {"hget", "h", "f"}, {"qpush", "q", "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = got[:0]
+	for _, r := range reps {
+		got = append(got, r.R.String())
+	}
+	if want := []string{"s2", "master", "s2", "master"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("batch routed to %q, want %q", got, want)
+	}
+
+	reps, err = rp.BatchDoContext(ForceMaster(context.Background()), BatchExec{{"get", "a"}, {"set", "a", 1}})
+	if err != nil || reps[0].R.String() != "master" || reps[1].R.String() != "master" {
+		t.Fatalf("forced batch returned %v, %v", reps, err)
+	}
+}
+
+func TestReplicatedPoolBatchErrors(t *testing.T) {
+	master := NewConPool(namedServer(t, "master").Addr(), 2)
+	down := NewConPool("127.0.0.1:1", 2)
+	rp := NewReplicatedPool(master, []*ConPool{down}, LeastInUse)
+	defer rp.Close()
+
+	reps, err := rp.BatchDo(BatchExec{{"set", "a", 1}, {"get", "a"}, {"get", "b"}})
+	if err == nil || err.Error() != "BatchDo: get 2 errors" {
+		t.Fatalf("BatchDo returned %v", err)
+	}
+	if reps[0].E != nil || reps[0].R.String() != "master" || reps[1].E == nil || reps[2].E == nil {
+		t.Fatalf("unexpected replies %+v", reps)
+	}
+}
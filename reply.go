@@ -2,16 +2,64 @@ package ssgo
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 )
 
-//const (
-//	ReplyOK          string = "ok"
-//	ReplyNotFound    string = "not_found"
-//	ReplyError       string = "error"
-//	ReplyFail        string = "fail"
-//	ReplyClientError string = "client_error"
-//)
+const (
+	ReplyOK          string = "ok"
+	ReplyNotFound    string = "not_found"
+	ReplyError       string = "error"
+	ReplyFail        string = "fail"
+	ReplyClientError string = "client_error"
+)
+
+// ErrBadReply is wrapped by the errors of typed commands whose reply could
+// not be decoded.
+var ErrBadReply = errors.New("ssgo: bad reply")
+
+// Errors matched by a StatusError of the same status, using errors.Is.
+var (
+	ErrNotFound    = errors.New("ssdb: not_found")
+	ErrFail        = errors.New("ssdb: fail")
+	ErrClientError = errors.New("ssdb: client_error")
+	ErrServerError = errors.New("ssdb: error")
+)
+
+// StatusError is a reply whose status is not "ok".
+type StatusError struct {
+	Cmd    string // command name, like "get"
+	Status string // reply status, like "not_found"
+	Msg    string // message sent by the server after the status, if any
+}
+
+func (e *StatusError) Error() string {
+	s := "ssdb: " + e.Cmd + ": " + e.Status
+	if e.Msg != "" {
+		s += ": " + e.Msg
+	}
+	return s
+}
+
+func (e *StatusError) Is(target error) bool {
+	switch e.Status {
+	case ReplyNotFound:
+		return target == ErrNotFound
+	case ReplyFail:
+		return target == ErrFail
+	case ReplyClientError:
+		return target == ErrClientError
+	case ReplyError:
+		return target == ErrServerError
+	}
+	return false
+}
+
+func newStatusError(cmd string, resp []string) *StatusError {
+	return &StatusError{Cmd: cmd, Status: resp[0], Msg: strings.Join(resp[1:], " ")}
+}
 
 type Reply []string
 
@@ -25,6 +73,12 @@ type Entry struct {
 	Value string `json:"v,omitempty"`
 }
 
+// ZEntry is a member of a sorted set.
+type ZEntry struct {
+	Key   string `json:"k,omitempty"`
+	Score int64  `json:"s"`
+}
+
 func (r Reply) String() string {
 
 	if len(r) > 0 {
@@ -138,6 +192,23 @@ func (r Reply) Map() map[string]string {
 	return m
 }
 
+// ZEntries decodes alternating keys and scores, as returned by the zset
+// range and scan commands.
+func (r Reply) ZEntries() ([]ZEntry, error) {
+	if len(r)%2 != 0 {
+		return nil, fmt.Errorf("%w: odd number of values in zset reply", ErrBadReply)
+	}
+	zs := make([]ZEntry, 0, len(r)/2)
+	for i := 0; i < len(r); i += 2 {
+		score, err := strconv.ParseInt(r[i+1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad score %q for %q", ErrBadReply, r[i+1], r[i])
+		}
+		zs = append(zs, ZEntry{r[i], score})
+	}
+	return zs, nil
+}
+
 // Json returns the map that marshals from the reply bytes as json in response .
 func (r Reply) Json(v interface{}) error {
 	return json.Unmarshal([]byte(r.String()), &v)
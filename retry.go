@@ -0,0 +1,149 @@
+package ssgo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+// Defaults of RetryPolicy.MinBackoff and RetryPolicy.MaxBackoff.
+const (
+	DefaultMinBackoff = 8 * time.Millisecond
+	DefaultMaxBackoff = 512 * time.Millisecond
+)
+
+// RetryPolicy makes a ConPool retry the commands that failed on a network
+// error. Only commands that are safe to run twice are retried, see
+// IsIdempotent, each time on a freshly dialed connection.
+type RetryPolicy struct {
+	// MaxAttempts is the number of tries, the first one included. Less
+	// than two disables retries.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry, doubled at each
+	// following one up to MaxBackoff. The actual wait is drawn at random
+	// below that bound.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Retryable reports whether a failed command may be tried again.
+	// Defaults to IsRetryable.
+	Retryable func(error) bool
+}
+
+// backoff returns the wait before retry n, starting at 1.
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	min, max := p.MinBackoff, p.MaxBackoff
+	if min <= 0 {
+		min = DefaultMinBackoff
+	}
+	if max <= 0 {
+		max = DefaultMaxBackoff
+	}
+	d := min
+	for i := 1; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return time.Duration(rand.Int63n(int64(d)) + 1)
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// IsRetryable reports whether err is a network failure, as opposed to an
+// error reply, a malformed reply or a cancelled context.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrTimeout) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// readOnly lists the commands that do not change the data.
+var readOnly = map[string]bool{
+	"ping": true, "info": true, "dbsize": true,
+	"get": true, "exists": true, "ttl": true, "strlen": true, "substr": true,
+	"getbit": true, "bitcount": true, "countbit": true,
+	"keys": true, "rkeys": true, "scan": true, "rscan": true, "multi_get": true,
+	"hget": true, "hexists": true, "hsize": true, "hgetall": true, "hkeys": true,
+	"hscan": true, "hrscan": true, "hlist": true, "hrlist": true, "multi_hget": true,
+	"zget": true, "zexists": true, "zsize": true, "zrank": true, "zrrank": true,
+	"zrange": true, "zrrange": true, "zkeys": true, "zscan": true, "zrscan": true,
+	"zcount": true, "zsum": true, "zavg": true, "zlist": true, "zrlist": true, "multi_zget": true,
+	"qsize": true, "qfront": true, "qback": true, "qget": true, "qrange": true,
+	"qslice": true, "qlist": true, "qrlist": true,
+}
+
+// idempotentWrites lists the writes that leave the same state when repeated.
+var idempotentWrites = map[string]bool{
+	"set": true, "setx": true, "expire": true, "del": true, "setbit": true,
+	"multi_set": true, "multi_del": true,
+	"hset": true, "hdel": true, "hclear": true, "multi_hset": true, "multi_hdel": true,
+	"zset": true, "zdel": true, "zclear": true, "zremrangebyscore": true,
+	"multi_zset": true, "multi_zdel": true,
+	"qset": true, "qclear": true,
+}
+
+// IsIdempotent reports whether cmd can be retried safely. Commands like
+// incr, getset, setnx, qpush or qpop are not.
+func IsIdempotent(cmd string) bool {
+	cmd = strings.ToLower(cmd)
+	return readOnly[cmd] || idempotentWrites[cmd]
+}
+
+// IsReadOnly reports whether cmd only reads data, and can be sent to a slave.
+func IsReadOnly(cmd string) bool {
+	return readOnly[strings.ToLower(cmd)]
+}
+
+// batchIdempotent reports whether every command of batch is idempotent.
+func batchIdempotent(batch BatchExec) bool {
+	for _, cmd := range batch {
+		if !IsIdempotent(cmdName(cmd)) {
+			return false
+		}
+	}
+	return true
+}
+
+// retry runs fn on a pooled connection and, when safe is set, again on fresh
+// ones while it fails on a retryable error. fn returns the error of the
+// connection itself.
+func (cr *ConPool) retry(ctx context.Context, safe bool, fn func(cn *Client) error) error {
+	p := cr.opts.Retry
+	for n := 1; ; n++ {
+		cn, err := cr.getClient(ctx, true, n > 1)
+		if err == nil {
+			err = fn(cn)
+			cn.Release()
+			cr.observe(err)
+		}
+		if err == nil || !safe || p == nil || n >= p.MaxAttempts || !p.retryable(err) {
+			return err
+		}
+		d := p.backoff(n)
+		cr.opts.log(slog.LevelInfo, "ssgo: retrying", "addr", cr.cAddr, "attempt", n+1, "backoff", d, "err", err)
+		cr.stats.retries.Add(1)
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		}
+	}
+}
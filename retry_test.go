@@ -0,0 +1,113 @@
+package ssgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRetryStaleConnection(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		return []string{"ok", "1"}
+	})
+	p := NewConPool(srv.Addr(), 2, WithRetry(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+	defer p.Close()
+
+	breakConns := func() {
+		t.Helper()
+		if _, err := p.Do("ping"); err != nil {
+			t.Fatal(err)
+		}
+		srv.CloseConns()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	breakConns()
+	if r, err := p.Do("get", "k"); err != nil || r.String() != "1" {
+		t.Fatalf("get returned %v, %v", r, err)
+	}
+	breakConns()
+	if reps, err := p.BatchDo(BatchExec{{"set", "a", 1}, {"hget", "h", "a"}}); err != nil || len(reps) != 2 {
+		t.Fatalf("BatchDo returned %v, %v", reps, err)
+	}
+	if st := p.Stats(); st.Retries != 2 {
+		t.Fatalf("retries %d, want 2", st.Retries)
+	}
+
+	breakConns()
+	if _, err := p.Do("incr", "k", 1); err == nil {
+		t.Fatal("incr was retried")
+	}
+	breakConns()
+	if _, err := p.BatchDo(BatchExec{{"get", "a"}, {"qpush", "q", "a"}}); err == nil {
+		t.Fatal("batch with qpush was retried")
+	}
+	if st := p.Stats(); st.Retries != 2 {
+		t.Fatalf("retries %d, want 2", st.Retries)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	calls := 0
+	p := NewConPool("127.0.0.1:1", 1, WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		Retryable: func(err error) bool {
+			calls++
+			return IsRetryable(err)
+		},
+	}))
+	defer p.Close()
+	if _, err := p.Do("get", "k"); err == nil {
+		t.Fatal("expected dial error")
+	}
+	if st := p.Stats(); calls != 2 || st.Dials != 3 || st.Retries != 2 {
+		t.Fatalf("classifier called %d times, stats %+v", calls, st)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.DoContext(ctx, "get", "k"); err != context.Canceled {
+		t.Fatalf("DoContext returned %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, true},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{&timeoutError{op: "read", err: io.EOF}, true},
+		{context.DeadlineExceeded, false},
+		{newStatusError("get", []string{ReplyNotFound}), false},
+		{ErrBadReply, false},
+		{ErrPoolExhausted, false},
+		{errors.New("other"), false},
+	} {
+		if got := IsRetryable(tc.err); got != tc.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+	for cmd, want := range map[string]bool{"get": true, "HSET": true, "zscan": true, "incr": false, "qpush": false, "getset": false} {
+		if got := IsIdempotent(cmd); got != want {
+			t.Errorf("IsIdempotent(%q) = %v, want %v", cmd, got, want)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := &RetryPolicy{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	for n, max := range []time.Duration{10, 20, 40, 50, 50} {
+		for i := 0; i < 100; i++ {
+			if d := p.backoff(n + 1); d <= 0 || d > max*time.Millisecond {
+				t.Fatalf("backoff(%d) = %v, want in (0, %v]", n+1, d, max*time.Millisecond)
+			}
+		}
+	}
+}
@@ -3,63 +3,237 @@ package ssgo
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 var (
 	ErrProtocolError = errors.New("ssdb protocol error")
+	// ErrTimeout matches, with errors.Is, every dial, read or write that
+	// ran past Options.DialTimeout, ReadTimeout or WriteTimeout.
+	ErrTimeout = errors.New("ssgo: i/o timeout")
 )
 
+// timeoutError wraps the net error of an operation that timed out.
+type timeoutError struct {
+	op  string
+	err error
+}
+
+func (e *timeoutError) Error() string        { return "ssgo: " + e.op + " timeout: " + e.err.Error() }
+func (e *timeoutError) Unwrap() error        { return e.err }
+func (e *timeoutError) Is(target error) bool { return target == ErrTimeout }
+func (e *timeoutError) Timeout() bool        { return true }
+func (e *timeoutError) Temporary() bool      { return true }
+
+// AuthError is returned when a new connection is refused by the auth
+// command, for a wrong Options.Password.
+type AuthError struct {
+	Err error // the StatusError returned by auth
+}
+
+func (e *AuthError) Error() string { return "ssgo: auth failed: " + e.Err.Error() }
+func (e *AuthError) Unwrap() error { return e.Err }
+
+// wrapTimeout tags err with ErrTimeout if it is a net timeout.
+func wrapTimeout(op string, err error) error {
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return &timeoutError{op: op, err: err}
+	}
+	return err
+}
+
 type Client struct {
+	commands
+
 	reader *bufio.Reader
-	sock   *net.TCPConn
+	sock   net.Conn
 	pool   *ConPool
+	opts   *Options
 	err    error
+	cmds   int64 // commands run since the last Release
+
+	created  time.Time // when the connection was dialed
+	lastUsed time.Time // when the connection was last released to its pool
+
+	dmu         sync.Mutex // guards the socket deadlines and the fields below
+	ctxDeadline time.Time  // deadline of the running command's context
+	interrupted bool       // the running command's context is done
 }
 
 type BatchExec [][]interface{}
 
-func Connect(ip string, port int) (*Client, error) {
-	addr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:%d", ip, port))
-	if err != nil {
-		return nil, err
+// Connect opens a single connection to ip:port. If ip is a
+// "unix:///path/to/ssdb.sock" address, port is ignored.
+func Connect(ip string, port int, opts ...Option) (*Client, error) {
+	if network, addr := splitAddr(ip); network != "tcp" {
+		return dial(context.Background(), network, addr, newOptions(opts))
 	}
-	sock, err := net.DialTCP("tcp", nil, addr)
-	if err != nil {
-		return nil, err
-	}
-	var c Client
-	c.sock = sock
-	c.reader = bufio.NewReader(sock)
-	return &c, nil
+	return dial(context.Background(), "tcp", net.JoinHostPort(ip, strconv.Itoa(port)), newOptions(opts))
 }
 
 func (c *Client) Do(args ...interface{}) (Reply, error) {
+	return c.DoContext(context.Background(), args...)
+}
 
-	if err := c.send(args); err != nil {
+// DoContext is like Do, but the socket deadline follows ctx and a blocked
+// send or recv is aborted as soon as ctx is done. A command interrupted this
+// way leaves the connection broken, so Release will close it.
+//
+// Options.Interceptors, if any, are run around the command.
+func (c *Client) DoContext(ctx context.Context, args ...interface{}) (Reply, error) {
+	if len(c.opts.Interceptors) > 0 {
+		return chainInterceptors(c.opts.Interceptors, c.exec)(ctx, args)
+	}
+	return c.exec(ctx, args)
+}
+
+func (c *Client) do(ctx context.Context, args []interface{}) (Reply, error) {
+	if c.err != nil {
+		// the connection may still hold the reply of a failed command
+		return nil, c.err
+	}
+
+	var buf bytes.Buffer
+	if err := encode(&buf, args); err != nil {
+		c.err = err
+		return nil, err
+	}
+	info := SpanInfo{Op: SpanSend, Cmd: cmdName(args), Key: cmdKey(args), ArgBytes: buf.Len()}
+	_, span := startSpan(ctx, c.opts, info)
+	err := c.write(buf.Bytes())
+	span.End(err, 0)
+	if err != nil {
 		c.err = err
 		return nil, err
 	}
 
+	info.Op, info.ArgBytes = SpanRecv, 0
+	_, span = startSpan(ctx, c.opts, info)
 	resp, err := c.recv()
+	span.End(err, replySize(resp))
 	if err != nil {
 		c.err = err
-	}
-	if err != nil || len(resp) < 1 {
 		return nil, err
 	}
-	if resp[0] != "ok" {
-		return nil, errors.New(resp[0])
+	return parseResp(args, resp)
+}
+
+// parseResp splits the raw response to args into its status and payload.
+func parseResp(args []interface{}, resp []string) (Reply, error) {
+	if len(resp) < 1 {
+		return nil, nil
+	}
+	if resp[0] != ReplyOK {
+		return nil, newStatusError(cmdName(args), resp)
 	}
 	return resp[1:], nil
 }
 
+func cmdName(args []interface{}) string {
+	if len(args) == 0 {
+		return ""
+	}
+	if s, ok := args[0].(string); ok {
+		return s
+	}
+	return fmt.Sprint(args[0])
+}
+
+// aLongTimeAgo is a deadline in the past, used to unblock pending I/O.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// watchContext applies the deadline of ctx to the socket and, if ctx can be
+// cancelled, interrupts pending I/O when it is. The returned stop function
+// must be called once the exchange is over; it restores the socket and
+// replaces err with ctx.Err() when the context was the cause.
+func (c *Client) watchContext(ctx context.Context) (stop func(error) error, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if ctx.Done() == nil {
+		return func(err error) error { return err }, nil
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		c.dmu.Lock()
+		c.ctxDeadline = deadline
+		c.sock.SetDeadline(deadline)
+		c.dmu.Unlock()
+	}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			c.dmu.Lock()
+			c.interrupted = true
+			c.sock.SetDeadline(aLongTimeAgo)
+			c.dmu.Unlock()
+		case <-quit:
+		}
+	}()
+
+	return func(err error) error {
+		close(quit)
+		<-done
+		if err != nil && err == c.err {
+			if ctxErr := contextErr(ctx); ctxErr != nil {
+				err = ctxErr
+				c.err = err
+			}
+		}
+		c.dmu.Lock()
+		c.ctxDeadline = time.Time{}
+		c.interrupted = false
+		if c.err == nil {
+			c.sock.SetDeadline(time.Time{})
+		}
+		c.dmu.Unlock()
+		return err
+	}, nil
+}
+
+// contextErr is ctx.Err(), but also reports a deadline that has passed
+// before ctx itself noticed.
+func contextErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d, ok := ctx.Deadline(); ok && !time.Now().Before(d) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// setDeadline arms the read or write deadline for one operation, honouring
+// both timeout and the running command's context.
+func (c *Client) setDeadline(set func(time.Time) error, timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	c.dmu.Lock()
+	defer c.dmu.Unlock()
+	if c.interrupted {
+		return
+	}
+	d := time.Now().Add(timeout)
+	if !c.ctxDeadline.IsZero() && c.ctxDeadline.Before(d) {
+		d = c.ctxDeadline
+	}
+	set(d)
+}
+
 func (c *Client) DoIgnoreErr(args ...interface{}) Reply {
 	r, e := c.Do(args...)
 	if e != nil {
@@ -69,86 +243,134 @@ func (c *Client) DoIgnoreErr(args ...interface{}) Reply {
 }
 
 func (c *Client) BatchDo(batch BatchExec) (reps []ReplyE, e error) {
+	return c.BatchDoContext(context.Background(), batch)
+}
+
+// BatchDoContext is like BatchDo, but bounded by ctx. Once ctx is done the
+// remaining commands are not sent and report ctx.Err().
+//
+// Commands are pipelined: up to Options.BatchChunkSize of them are written
+// in a single buffered write, then their replies are read back in order.
+func (c *Client) BatchDoContext(ctx context.Context, batch BatchExec) (reps []ReplyE, e error) {
+	stop, err := c.watchContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, span := startSpan(ctx, c.opts, SpanInfo{Op: SpanBatch, Cmd: "pipeline"})
+	defer func() {
+		n := 0
+		for _, r := range reps {
+			n += replySize(r.R)
+		}
+		span.End(e, n)
+	}()
 	l := len(batch)
 	replys := make([]ReplyE, l)
-	errCount := 0
+	chunk := c.opts.BatchChunkSize
 
-	for i, args := range batch {
-		replys[i].R, replys[i].E = c.Do(args...)
-		if replys[i].E != nil {
-			errCount++
+	for i := 0; i < l; i += chunk {
+		j := i + chunk
+		if j > l {
+			j = l
+		}
+		if ctx.Err() != nil {
+			for k := i; k < j; k++ {
+				replys[k].E = ctx.Err()
+			}
+			continue
+		}
+		if len(c.opts.Interceptors) > 0 {
+			c.pipelineIntercepted(ctx, batch[i:j], replys[i:j])
+		} else {
+			c.pipeline(ctx, batch[i:j], replys[i:j], nil)
+		}
+	}
+	stop(nil)
+
+	if ctxErr := contextErr(ctx); c.err != nil && ctxErr != nil {
+		for i := range replys {
+			if replys[i].E == c.err {
+				replys[i].E = ctxErr
+			}
 		}
+		c.err = ctxErr
 	}
 
+	errCount := 0
+	for _, r := range replys {
+		if r.E != nil {
+			errCount++
+		}
+	}
 	if errCount != 0 {
 		e = fmt.Errorf("BatchDo: get %d errors", errCount)
 	}
 	return replys, e
 }
 
-func (c *Client) Set(key string, val string) (interface{}, error) {
-	resp, err := c.Do("set", key, val)
-	if err != nil {
-		return nil, err
+// pipeline writes cmds in one go and reads their replies into reps. A
+// command that cannot be encoded is skipped and reports its own error; a
+// network error breaks the connection and fails every pending command.
+// done, if not nil, is called with the index of each reply once it is set.
+func (c *Client) pipeline(ctx context.Context, cmds BatchExec, reps []ReplyE, done func(i int)) {
+	fail := func(i int, err error) {
+		reps[i].E = err
+		if done != nil {
+			done(i)
+		}
 	}
-	if len(resp) == 2 && resp[0] == "ok" {
-		return true, nil
+	if c.err != nil {
+		for i := range reps {
+			fail(i, c.err)
+		}
+		return
 	}
-	return nil, fmt.Errorf("bad response")
-}
 
-// TODO: Will somebody write addition semantic methods?
-func (c *Client) Get(key string) (interface{}, error) {
-	resp, err := c.Do("get", key)
-	if err != nil {
-		return nil, err
-	}
-	if len(resp) == 2 && resp[0] == "ok" {
-		return resp[1], nil
+	var buf bytes.Buffer
+	sent := make([]int, 0, len(cmds))
+	for i, args := range cmds {
+		n := buf.Len()
+		if err := encode(&buf, args); err != nil {
+			buf.Truncate(n)
+			fail(i, err)
+			continue
+		}
+		sent = append(sent, i)
 	}
-	if resp[0] == "not_found" {
-		return nil, nil
+	if len(sent) == 0 {
+		return
 	}
-	return nil, fmt.Errorf("bad response")
-}
+	c.cmds += int64(len(sent))
 
-func (c *Client) Del(key string) (interface{}, error) {
-	resp, err := c.Do("del", key)
+	_, span := startSpan(ctx, c.opts, SpanInfo{Op: SpanSend, Cmd: "pipeline", ArgBytes: buf.Len()})
+	err := c.write(buf.Bytes())
+	span.End(err, 0)
 	if err != nil {
-		return nil, err
-	}
-
-	//response looks like this: [ok 1]
-	if len(resp) > 0 && resp[0] == "ok" {
-		return true, nil
+		c.err = err
+		for _, i := range sent {
+			fail(i, err)
+		}
+		return
 	}
-	return nil, fmt.Errorf("bad response:resp:%v:", resp)
-}
 
-func (c *Client) MultiHSet(name string, obj interface{}, keys ...string) error {
-	args := Args{"multi_hset", name}.AddFlat(obj, keys...)
-	_, e := c.Do(args...)
-	return e
-}
-
-func (c *Client) MultiHGet(name string, obj interface{}, keys ...string) error {
-	var (
-		rep Reply
-		e   error
-	)
-	if len(keys) > 0 {
-		args := []interface{}{"multi_hget", name}
-		for _, v := range keys {
-			args = append(args, v)
+	_, span = startSpan(ctx, c.opts, SpanInfo{Op: SpanRecv, Cmd: "pipeline"})
+	received := 0
+	defer func() { span.End(c.err, received) }()
+	for k, i := range sent {
+		resp, err := c.recv()
+		if err != nil {
+			c.err = err
+			for _, i := range sent[k:] {
+				fail(i, err)
+			}
+			return
+		}
+		received += replySize(resp)
+		reps[i].R, reps[i].E = parseResp(cmds[i], resp)
+		if done != nil {
+			done(i)
 		}
-		rep, e = c.Do(args...)
-	} else {
-		rep, e = c.Do("hgetall", name)
-	}
-	if e != nil {
-		return e
 	}
-	return ScanStruct(rep, obj)
 }
 
 func (c *Client) GenAutoIncId(name string) int64 {
@@ -165,6 +387,23 @@ func (c *Client) Send(args ...interface{}) error {
 
 func (c *Client) send(args []interface{}) error {
 	var buf bytes.Buffer
+	if err := encode(&buf, args); err != nil {
+		return err
+	}
+	return c.write(buf.Bytes())
+}
+
+func (c *Client) write(b []byte) error {
+	c.setDeadline(c.sock.SetWriteDeadline, c.opts.WriteTimeout)
+	n, err := c.sock.Write(b)
+	if m := c.opts.Metrics; m != nil {
+		m.bytesSent.Add(int64(n))
+	}
+	return wrapTimeout("write", err)
+}
+
+// encode appends one command in the SSDB wire format to buf.
+func encode(buf *bytes.Buffer, args []interface{}) error {
 	for _, arg := range args {
 		var s string
 		switch arg := arg.(type) {
@@ -206,8 +445,7 @@ func (c *Client) send(args []interface{}) error {
 		buf.WriteByte('\n')
 	}
 	buf.WriteByte('\n')
-	_, err := c.sock.Write(buf.Bytes())
-	return err
+	return nil
 }
 
 func (c *Client) Recv() ([]string, error) {
@@ -215,32 +453,39 @@ func (c *Client) Recv() ([]string, error) {
 }
 
 func (c *Client) recv() ([]string, error) {
+	c.setDeadline(c.sock.SetReadDeadline, c.opts.ReadTimeout)
+	var n int64 // bytes consumed, for Options.Metrics
+	if m := c.opts.Metrics; m != nil {
+		defer func() { m.bytesRecv.Add(n) }()
+	}
 	resp := []string{}
 	bb := bytes.NewBuffer(nil)
 	for {
 		l, _, e := c.reader.ReadLine()
 		if e != nil {
-			return nil, e
+			return nil, wrapTimeout("read", e)
 		}
+		n += int64(len(l)) + 1
 		if len(l) == 0 {
 			//empty line found
 			break
 		}
 		size, e := strconv.Atoi(string(l))
 		if e != nil {
-			return nil, e
+			return nil, c.protocolError(e)
 		}
 		if size < 0 {
-			return nil, ErrProtocolError
+			return nil, c.protocolError(ErrProtocolError)
 		}
 		bb.Reset()
-		_, e = io.CopyN(bb, c.reader, int64(size+1))
+		copied, e := io.CopyN(bb, c.reader, int64(size+1))
+		n += copied
 		if e != nil {
-			return nil, e
+			return nil, wrapTimeout("read", e)
 		}
 		buf := bb.Bytes()
 		if buf[size] != '\n' {
-			return nil, ErrProtocolError
+			return nil, c.protocolError(ErrProtocolError)
 		}
 		//		fmt.Println("read buf:", string(bb.Bytes()[:size]))
 
@@ -249,14 +494,29 @@ func (c *Client) recv() ([]string, error) {
 	return resp, nil
 }
 
+// protocolError logs a malformed reply and returns err.
+func (c *Client) protocolError(err error) error {
+	c.opts.log(slog.LevelError, "ssgo: protocol error", "addr", c.sock.RemoteAddr().String(), "err", err)
+	return err
+}
+
 // Close The Client Connection
 func (c *Client) close() error {
 	return c.sock.Close()
 }
 
 func (c *Client) Release() error {
+	if c.pool != nil {
+		c.pool.stats.commands.Add(c.cmds)
+		c.cmds = 0
+	}
 	if c.err != nil {
 		// if client have net error, try to close it
+		c.opts.log(slog.LevelInfo, "ssgo: discarding broken connection", "addr", c.sock.RemoteAddr().String(), "err", c.err)
+		if c.pool != nil {
+			c.pool.stats.closedErrors.Add(1)
+			return c.pool.discard(c)
+		}
 		return c.close()
 	} else if c.pool != nil {
 		c.pool.push(c)
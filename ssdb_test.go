@@ -1,8 +1,17 @@
 package ssgo
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
 	"math/rand"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 var (
@@ -45,14 +54,14 @@ func TestClientMultiFunc(t *testing.T) {
 	if e != nil {
 		t.Error(e)
 	}
-	t.Log("%v\n", s)
+	t.Logf("%v\n", s)
 
 	s2 := ss1{}
 	e = cn.MultiHGet("test1", &s2, "u", "s")
 	if e != nil {
 		t.Error(e)
 	}
-	t.Log("%v\n", s2)
+	t.Logf("%v\n", s2)
 }
 
 func TestBatchDo(t *testing.T) {
@@ -151,3 +160,432 @@ func BenchmarkSSDBGo_10k_10(b *testing.B) {
 func BenchmarkSSDBGo_10k_50(b *testing.B) {
 	benchmarkSSDBGo(10*1024, 50, b)
 }
+
+// benchmarkSSDBGoBatch runs the hset workload of benchmarkSSDBGo as one
+// BatchExec per iteration, either pipelined by BatchDo or one Do at a time.
+func benchmarkSSDBGoBatch(valSize, batchSize int, pipelined bool, b *testing.B) {
+	val := makeValue(valSize)
+	cn, _ := pool.GetClient()
+	defer cn.Release()
+	defer cn.Do("hclear", ssgoTestKey1)
+	batch := make(BatchExec, batchSize)
+	for i := range batch {
+		batch[i] = []interface{}{"hset", ssgoTestKey1, i, val}
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if pipelined {
+			if _, e := cn.BatchDo(batch); e != nil {
+				b.Error(e)
+			}
+			continue
+		}
+		for _, args := range batch {
+			if _, e := cn.Do(args...); e != nil {
+				b.Error(e)
+			}
+		}
+	}
+}
+
+// go test -run=none -bench=BenchmarkSSDBGo_Batch
+func BenchmarkSSDBGo_Batch_1k_10(b *testing.B) {
+	benchmarkSSDBGoBatch(1*1024, 10, true, b)
+}
+func BenchmarkSSDBGo_Batch_1k_50(b *testing.B) {
+	benchmarkSSDBGoBatch(1*1024, 50, true, b)
+}
+
+func BenchmarkSSDBGo_Batch_10k_10(b *testing.B) {
+	benchmarkSSDBGoBatch(10*1024, 10, true, b)
+}
+
+func BenchmarkSSDBGo_Batch_10k_50(b *testing.B) {
+	benchmarkSSDBGoBatch(10*1024, 50, true, b)
+}
+
+func BenchmarkSSDBGo_Serial_1k_10(b *testing.B) {
+	benchmarkSSDBGoBatch(1*1024, 10, false, b)
+}
+func BenchmarkSSDBGo_Serial_1k_50(b *testing.B) {
+	benchmarkSSDBGoBatch(1*1024, 50, false, b)
+}
+
+func BenchmarkSSDBGo_Serial_10k_10(b *testing.B) {
+	benchmarkSSDBGoBatch(10*1024, 10, false, b)
+}
+
+func BenchmarkSSDBGo_Serial_10k_50(b *testing.B) {
+	benchmarkSSDBGoBatch(10*1024, 50, false, b)
+}
+
+// testServer is a minimal in-process stand-in for SSDB. Every request is
+// passed to handle; a nil response stalls the connection for good.
+type testServer struct {
+	ln     net.Listener
+	handle func(req []string) []string
+	// password, if set, must be sent with auth before any other command.
+	password string
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newTestServer(t testing.TB, handle func(req []string) []string) *testServer {
+	return newAuthTestServer(t, "", handle)
+}
+
+func newAuthTestServer(t testing.TB, password string, handle func(req []string) []string) *testServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{ln: ln, handle: handle, password: password}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *testServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+// CloseConns drops every accepted connection, as a server restart would.
+func (s *testServer) CloseConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	c := &Client{sock: conn, reader: bufio.NewReader(conn), opts: newOptions(nil)}
+	authed := s.password == ""
+	for {
+		req, err := c.recv()
+		if err != nil {
+			return
+		}
+		var resp []string
+		switch {
+		case req[0] == "auth":
+			authed = len(req) == 2 && req[1] == s.password
+			resp = []string{"error", "invalid password"}
+			if authed {
+				resp = []string{"ok", "1"}
+			}
+		case !authed:
+			resp = []string{"noauth", "authentication required"}
+		default:
+			resp = s.handle(req)
+		}
+		if resp == nil {
+			io.Copy(io.Discard, conn)
+			return
+		}
+		args := make([]interface{}, len(resp))
+		for i, v := range resp {
+			args[i] = v
+		}
+		if err := c.send(args); err != nil {
+			return
+		}
+	}
+}
+
+func TestDoContextTimeout(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		if req[0] == "ping" {
+			return []string{"ok"}
+		}
+		return nil
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	cn, err := p.GetClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := cn.DoContext(ctx, "get", "k"); err != context.DeadlineExceeded {
+		t.Fatalf("DoContext returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if cn.err == nil {
+		t.Fatal("interrupted connection is not marked broken")
+	}
+	cn.Release()
+	if len(p.conns) != 0 {
+		t.Fatal("broken connection returned to the pool")
+	}
+
+	if _, err := p.DoContext(context.Background(), "ping"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchDoContextCancel(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		if req[0] == "ping" {
+			return []string{"ok"}
+		}
+		return nil
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	reps, err := p.BatchDoContext(ctx, BatchExec{{"ping"}, {"get", "k"}, {"ping"}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if reps[0].E != nil {
+		t.Errorf("reply 0: %v", reps[0].E)
+	}
+	for _, r := range reps[1:] {
+		if r.E != context.Canceled {
+			t.Errorf("got %v, want %v", r.E, context.Canceled)
+		}
+	}
+	if len(p.conns) != 0 {
+		t.Fatal("broken connection returned to the pool")
+	}
+}
+
+func TestBatchDoPipeline(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		if req[0] == "get" && req[1] == "missing" {
+			return []string{"not_found"}
+		}
+		return []string{"ok", req[1]}
+	})
+	p := NewConPool(srv.Addr(), 1, WithBatchChunkSize(7))
+	defer p.Close()
+
+	var batch BatchExec
+	for i := 0; i < 100; i++ {
+		batch = append(batch, []interface{}{"get", i})
+	}
+	batch = append(batch, []interface{}{"get", "missing"}, []interface{}{"get", func() {}}, []interface{}{"get", "last"})
+
+	reps, err := p.BatchDo(batch)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for i := 0; i < 100; i++ {
+		if reps[i].E != nil || reps[i].R.Int() != i {
+			t.Fatalf("reply %d: %v %v", i, reps[i].R, reps[i].E)
+		}
+	}
+	if reps[100].E == nil || reps[101].E == nil {
+		t.Fatalf("expected errors, got %v %v", reps[100].E, reps[101].E)
+	}
+	if reps[102].E != nil || reps[102].R.String() != "last" {
+		t.Fatalf("reply 102: %v %v", reps[102].R, reps[102].E)
+	}
+	if len(p.conns) != 1 {
+		t.Fatal("connection not returned to the pool")
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		if req[0] == "ping" {
+			return []string{"ok"}
+		}
+		return nil
+	})
+	host, port, _ := net.SplitHostPort(srv.Addr())
+	portNum, _ := strconv.Atoi(port)
+	cn, err := Connect(host, portNum, WithReadTimeout(30*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cn.Release()
+
+	if _, err := cn.Do("ping"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, err := cn.Do("ping"); err != nil {
+		t.Fatalf("read deadline leaked into the next command: %v", err)
+	}
+	if _, err := cn.Do("get", "k"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Do returned %v, want %v", err, ErrTimeout)
+	}
+}
+
+func TestDoAfterReadTimeout(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		if req[0] == "slow" {
+			time.Sleep(50 * time.Millisecond)
+			return []string{"ok", "slow-reply"}
+		}
+		return []string{"ok", "pong"}
+	})
+	host, port, _ := net.SplitHostPort(srv.Addr())
+	portNum, _ := strconv.Atoi(port)
+	cn, err := Connect(host, portNum, WithReadTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cn.Release()
+
+	if _, err := cn.Do("slow"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Do returned %v, want %v", err, ErrTimeout)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if r, err := cn.Do("ping"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Do on a broken connection returned %v, %v", r, err)
+	}
+	if reps, err := cn.BatchDo(BatchExec{{"ping"}}); err == nil || !errors.Is(reps[0].E, ErrTimeout) {
+		t.Fatalf("BatchDo on a broken connection returned %v, %v", reps, err)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		switch req[0] {
+		case "get":
+			return []string{"not_found"}
+		case "hgetall":
+			return []string{"ok"}
+		case "set":
+			return []string{"client_error", "wrong number of arguments"}
+		}
+		return []string{"error"}
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	_, err := p.Do("set", "k")
+	var se *StatusError
+	if !errors.As(err, &se) || !errors.Is(err, ErrClientError) {
+		t.Fatalf("Do returned %v, want a client_error", err)
+	}
+	if se.Cmd != "set" || se.Msg != "wrong number of arguments" {
+		t.Fatalf("got %#v", se)
+	}
+
+	cn, _ := p.GetClient()
+	defer cn.Release()
+	if _, err := cn.Get("k"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get returned %v, want %v", err, ErrNotFound)
+	}
+	if err := cn.MultiHGet("h", &ss1{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("MultiHGet returned %v, want %v", err, ErrNotFound)
+	}
+	reps, _ := cn.BatchDo(BatchExec{{"get", "k"}, {"info"}})
+	if !errors.Is(reps[0].E, ErrNotFound) || !errors.Is(reps[1].E, ErrServerError) {
+		t.Fatalf("BatchDo returned %v, %v", reps[0].E, reps[1].E)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	srv := newAuthTestServer(t, "secret", func(req []string) []string {
+		return []string{"ok"}
+	})
+	host, port, _ := net.SplitHostPort(srv.Addr())
+	portNum, _ := strconv.Atoi(port)
+
+	var ae *AuthError
+	if _, err := Connect(host, portNum, WithPassword("wrong")); !errors.As(err, &ae) {
+		t.Fatalf("Connect returned %v, want an AuthError", err)
+	}
+
+	p := NewConPool(srv.Addr(), 1, WithPassword("secret"))
+	defer p.Close()
+	if _, err := p.Do("ping"); err != nil {
+		t.Fatal(err)
+	}
+	// A redial after the server dropped us authenticates again.
+	srv.CloseConns()
+	cn, _ := p.GetClient()
+	cn.Do("ping")
+	cn.Release()
+	if _, err := p.Do("ping"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDialer(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		return []string{"ok", "pong"}
+	})
+	var dialed []string
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, network+" "+addr)
+		client, server := net.Pipe()
+		go srv.serveConn(server)
+		return client, nil
+	}
+	p := NewConPool("unix:///tmp/ssdb.sock", 1, WithDialer(dialer), WithReadTimeout(time.Second))
+	defer p.Close()
+
+	r, err := p.Do("ping")
+	if err != nil || r.String() != "pong" {
+		t.Fatalf("Do returned %v, %v", r, err)
+	}
+	if len(dialed) != 1 || dialed[0] != "unix /tmp/ssdb.sock" {
+		t.Fatalf("dialed %q", dialed)
+	}
+}
+
+func TestDialerContext(t *testing.T) {
+	dialer := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, &net.OpError{Op: "dial", Net: network, Err: ctx.Err()}
+	}
+	p := NewConPool("127.0.0.1:1", 1, WithDialer(dialer), WithDialTimeout(2*time.Second))
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := p.DoContext(ctx, "ping"); err != context.DeadlineExceeded {
+		t.Fatalf("DoContext returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("DoContext took %v", d)
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssdb.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip(err)
+	}
+	srv := &testServer{ln: ln, handle: func(req []string) []string {
+		return []string{"ok", "pong"}
+	}}
+	go srv.serve()
+	defer ln.Close()
+
+	cn, err := Connect("unix://"+path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cn.Release()
+	if r, err := cn.Do("ping"); err != nil || r.String() != "pong" {
+		t.Fatalf("Do returned %v, %v", r, err)
+	}
+}
@@ -0,0 +1,66 @@
+package ssgo
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PoolStats is a snapshot of the state and counters of a ConPool.
+type PoolStats struct {
+	TotalConns int // open connections
+	IdleConns  int // open connections waiting in the pool
+	InUse      int // open connections handed out by GetClient
+
+	Dials        int64 // connection attempts
+	DialFailures int64 // connection attempts that failed, auth included
+
+	Waits        int64         // GetClient calls that waited for MaxActive
+	WaitDuration time.Duration // total time spent in those waits
+
+	ClosedErrors int64 // connections closed on release after an error
+	ClosedStale  int64 // connections closed for IdleTimeout, MaxLifetime, TestOnBorrow or a retry
+
+	Commands int64 // commands run on released connections
+	Retries  int64 // commands tried again after a network error, see RetryPolicy
+
+	BreakerTrips int64 // times the circuit breaker opened, see BreakerPolicy
+}
+
+// poolCounters are the cumulative PoolStats of a ConPool.
+type poolCounters struct {
+	dials        atomic.Int64
+	dialFailures atomic.Int64
+	waits        atomic.Int64
+	waitNanos    atomic.Int64
+	closedErrors atomic.Int64
+	closedStale  atomic.Int64
+	commands     atomic.Int64
+	retries      atomic.Int64
+	breakerTrips atomic.Int64
+}
+
+// Stats returns a snapshot of the pool state and counters.
+func (cr *ConPool) Stats() PoolStats {
+	cr.mu.Lock()
+	total := cr.active
+	cr.mu.Unlock()
+	idle := len(cr.conns)
+	inUse := total - idle
+	if inUse < 0 {
+		inUse = 0
+	}
+	return PoolStats{
+		TotalConns:   total,
+		IdleConns:    idle,
+		InUse:        inUse,
+		Dials:        cr.stats.dials.Load(),
+		DialFailures: cr.stats.dialFailures.Load(),
+		Waits:        cr.stats.waits.Load(),
+		WaitDuration: time.Duration(cr.stats.waitNanos.Load()),
+		ClosedErrors: cr.stats.closedErrors.Load(),
+		ClosedStale:  cr.stats.closedStale.Load(),
+		Commands:     cr.stats.commands.Load(),
+		Retries:      cr.stats.retries.Load(),
+		BreakerTrips: cr.stats.breakerTrips.Load(),
+	}
+}
@@ -0,0 +1,164 @@
+package ssgo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span operations reported in SpanInfo.Op.
+const (
+	SpanCommand  = "command"   // one command, from Do or within BatchDo
+	SpanBatch    = "batch"     // a whole BatchDo
+	SpanPoolWait = "pool.wait" // GetClient waiting for a connection
+	SpanSend     = "send"      // writing requests to the socket
+	SpanRecv     = "recv"      // reading replies from the socket
+)
+
+// SpanInfo describes the work a span covers.
+type SpanInfo struct {
+	Op       string // one of the Span* constants
+	Cmd      string // command name, empty for SpanPoolWait; "pipeline" for a BatchDo send or recv
+	Key      string // first argument of the command, if any
+	ArgBytes int    // encoded size of the request(s)
+}
+
+// Tracer starts spans for the work done by clients and pools given to it
+// with WithTracer. The returned context is passed to nested spans.
+type Tracer interface {
+	StartSpan(ctx context.Context, info SpanInfo) (context.Context, Span)
+}
+
+// Span is work in progress, ended once with its outcome.
+type Span interface {
+	End(err error, replyBytes int)
+}
+
+// WithTracer sets Options.Tracer and traces every command.
+func WithTracer(t Tracer) Option {
+	return func(o *Options) {
+		o.Tracer = t
+		o.Interceptors = append(o.Interceptors, traceIntercept(t))
+	}
+}
+
+func traceIntercept(t Tracer) Interceptor {
+	return func(ctx context.Context, cmd []interface{}, next Invoker) (Reply, error) {
+		var buf bytes.Buffer
+		encode(&buf, cmd)
+		ctx, span := t.StartSpan(ctx, SpanInfo{Op: SpanCommand, Cmd: cmdName(cmd), Key: cmdKey(cmd), ArgBytes: buf.Len()})
+		r, err := next(ctx, cmd)
+		span.End(err, replySize(r))
+		return r, err
+	}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(error, int) {}
+
+// startSpan starts a span with o.Tracer, if any.
+func startSpan(ctx context.Context, o *Options, info SpanInfo) (context.Context, Span) {
+	if o.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return o.Tracer.StartSpan(ctx, info)
+}
+
+// cmdKey returns the key of a command, for spans and logs. The password
+// given to auth is left out.
+func cmdKey(args []interface{}) string {
+	if len(args) < 2 || strings.EqualFold(cmdName(args), "auth") {
+		return ""
+	}
+	switch k := args[1].(type) {
+	case string:
+		return k
+	case []byte:
+		return string(k)
+	}
+	return fmt.Sprint(args[1])
+}
+
+func replySize(r []string) int {
+	n := 0
+	for _, s := range r {
+		n += len(s)
+	}
+	return n
+}
+
+// RecordedSpan is a span kept by a SpanRecorder.
+type RecordedSpan struct {
+	SpanInfo
+	ID         int // 1-based position in SpanRecorder.Spans
+	ParentID   int // 0 for a root span
+	Start, End time.Time
+	Err        error
+	ReplyBytes int
+}
+
+// SpanRecorder is a Tracer keeping every span in memory, to let tests
+// check which commands a code path runs.
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+type spanKey struct{}
+
+func (r *SpanRecorder) StartSpan(ctx context.Context, info SpanInfo) (context.Context, Span) {
+	s := &RecordedSpan{SpanInfo: info, Start: time.Now()}
+	if parent, ok := ctx.Value(spanKey{}).(*RecordedSpan); ok {
+		s.ParentID = parent.ID
+	}
+	r.mu.Lock()
+	r.spans = append(r.spans, s)
+	s.ID = len(r.spans)
+	r.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), recordedSpan{r, s}
+}
+
+type recordedSpan struct {
+	r *SpanRecorder
+	s *RecordedSpan
+}
+
+func (rs recordedSpan) End(err error, replyBytes int) {
+	rs.r.mu.Lock()
+	rs.s.End, rs.s.Err, rs.s.ReplyBytes = time.Now(), err, replyBytes
+	rs.r.mu.Unlock()
+}
+
+// Spans returns a copy of the spans recorded so far, in start order.
+func (r *SpanRecorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := make([]RecordedSpan, len(r.spans))
+	for i, s := range r.spans {
+		spans[i] = *s
+	}
+	return spans
+}
+
+// Commands returns the names and keys, like "get k", of the recorded
+// SpanCommand spans, in start order.
+func (r *SpanRecorder) Commands() []string {
+	var cmds []string
+	for _, s := range r.Spans() {
+		if s.Op == SpanCommand {
+			cmds = append(cmds, s.Cmd+" "+s.Key)
+		}
+	}
+	return cmds
+}
+
+// Reset forgets the recorded spans.
+func (r *SpanRecorder) Reset() {
+	r.mu.Lock()
+	r.spans = nil
+	r.mu.Unlock()
+}
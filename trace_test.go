@@ -0,0 +1,84 @@
+package ssgo
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpanRecorder(t *testing.T) {
+	srv := newTestServer(t, func(req []string) []string {
+		if req[0] == "get" {
+			return []string{"ok", "value"}
+		}
+		return []string{"ok"}
+	})
+	rec := &SpanRecorder{}
+	p := NewConPool(srv.Addr(), 1, WithTracer(rec), WithMaxActive(1))
+	defer p.Close()
+
+	if _, err := p.Do("get", "k"); err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	for _, s := range rec.Spans() {
+		ops = append(ops, s.Op)
+	}
+	if !reflect.DeepEqual(ops, []string{SpanCommand, SpanSend, SpanRecv}) {
+		t.Fatalf("recorded %q", ops)
+	}
+	spans := rec.Spans()
+	cmd, send, recv := spans[0], spans[1], spans[2]
+	if cmd.Cmd != "get" || cmd.Key != "k" || cmd.ReplyBytes != 5 || cmd.Err != nil {
+		t.Fatalf("command span %+v", cmd)
+	}
+	// "3\nget\n1\nk\n\n"
+	if send.ParentID != cmd.ID || send.ArgBytes != 11 || recv.ParentID != cmd.ID || recv.ReplyBytes != 7 {
+		t.Fatalf("send span %+v, recv span %+v", send, recv)
+	}
+
+	rec.Reset()
+	p.BatchDo(BatchExec{{"set", "a", 1}, {"get", "a"}})
+	if got := rec.Commands(); !reflect.DeepEqual(got, []string{"set a", "get a"}) {
+		t.Fatalf("recorded commands %q", got)
+	}
+	if spans := rec.Spans(); spans[0].Op != SpanBatch || spans[0].ReplyBytes != 5 {
+		t.Fatalf("batch span %+v", spans[0])
+	}
+
+	rec.Reset()
+	cn, _ := p.GetClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	p.DoContext(ctx, "ping")
+	cn.Release()
+	for _, s := range rec.Spans() {
+		if s.Op == SpanPoolWait && s.Err == context.DeadlineExceeded {
+			return
+		}
+	}
+	t.Fatalf("no failed pool wait span in %+v", rec.Spans())
+}
+
+func TestSpanAuthKey(t *testing.T) {
+	srv := newAuthTestServer(t, "s3cret", func(req []string) []string {
+		return []string{"ok"}
+	})
+	rec := &SpanRecorder{}
+	p := NewConPool(srv.Addr(), 1, WithPassword("s3cret"), WithTracer(rec))
+	defer p.Close()
+
+	if _, err := p.Do("get", "k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Do("auth", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range rec.Spans() {
+		if strings.Contains(s.Key, "s3cret") {
+			t.Fatalf("password in span %+v", s)
+		}
+	}
+}
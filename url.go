@@ -0,0 +1,146 @@
+package ssgo
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultPort is the SSDB port used by ParseURL when the URL has none.
+const DefaultPort = 8888
+
+// URLOptions is a ConPool configuration parsed from a URL by ParseURL.
+type URLOptions struct {
+	Network string // "tcp" or "unix"
+	Addr    string // host:port, or the socket path
+	MaxConn int    // idle connections kept, see NewConPool
+	Options
+}
+
+// ParseURL parses a pool configuration such as
+//
+//	ssdb://:password@host:8888?max_conn=32&dial_timeout=2s&read_timeout=1s&tls=true
+//	unix:///var/run/ssdb.sock?password=secret
+//
+// The ssdbs scheme is ssdb with tls=true. Query parameters set the field of
+// Options with the same name, in snake case: max_conn, max_active,
+// wait_timeout, idle_timeout, max_lifetime, test_on_borrow, dial_timeout,
+// read_timeout, write_timeout, batch_chunk_size, password, tls,
+// tls_server_name, tls_insecure_skip_verify and tls_handshake_timeout.
+// Durations use time.ParseDuration syntax. Unknown parameters are an error.
+func ParseURL(rawurl string) (*URLOptions, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		// not err itself, which quotes rawurl
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return nil, fmt.Errorf("ssgo: bad URL: %w", err)
+	}
+	redacted := redactURL(u)
+	uo := &URLOptions{}
+	useTLS := false
+	switch u.Scheme {
+	case "ssdb", "ssdbs":
+		useTLS = u.Scheme == "ssdbs"
+		if u.Host == "" {
+			return nil, fmt.Errorf("ssgo: bad URL %q: missing host", redacted)
+		}
+		uo.Network, uo.Addr = "tcp", u.Host
+		if u.Port() == "" {
+			uo.Addr = net.JoinHostPort(u.Hostname(), strconv.Itoa(DefaultPort))
+		}
+	case "unix":
+		if u.Path == "" {
+			return nil, fmt.Errorf("ssgo: bad URL %q: missing socket path", redacted)
+		}
+		uo.Network, uo.Addr = "unix", u.Path
+	default:
+		return nil, fmt.Errorf("ssgo: bad URL %q: unsupported scheme %q", redacted, u.Scheme)
+	}
+	if u.User != nil {
+		if pw, ok := u.User.Password(); ok {
+			uo.Password = pw
+		} else {
+			uo.Password = u.User.Username()
+		}
+	}
+
+	var tlsCfg tls.Config
+	for k, vs := range u.Query() {
+		v := vs[len(vs)-1]
+		var err error
+		switch k {
+		case "max_conn":
+			uo.MaxConn, err = strconv.Atoi(v)
+		case "max_active":
+			uo.MaxActive, err = strconv.Atoi(v)
+		case "batch_chunk_size":
+			uo.BatchChunkSize, err = strconv.Atoi(v)
+		case "wait_timeout":
+			uo.WaitTimeout, err = time.ParseDuration(v)
+		case "idle_timeout":
+			uo.IdleTimeout, err = time.ParseDuration(v)
+		case "max_lifetime":
+			uo.MaxLifetime, err = time.ParseDuration(v)
+		case "dial_timeout":
+			uo.DialTimeout, err = time.ParseDuration(v)
+		case "read_timeout":
+			uo.ReadTimeout, err = time.ParseDuration(v)
+		case "write_timeout":
+			uo.WriteTimeout, err = time.ParseDuration(v)
+		case "tls_handshake_timeout":
+			uo.TLSHandshakeTimeout, err = time.ParseDuration(v)
+		case "test_on_borrow":
+			uo.TestOnBorrow, err = strconv.ParseBool(v)
+		case "password":
+			uo.Password = v
+		case "tls":
+			useTLS, err = strconv.ParseBool(v)
+		case "tls_server_name":
+			tlsCfg.ServerName = v
+		case "tls_insecure_skip_verify":
+			tlsCfg.InsecureSkipVerify, err = strconv.ParseBool(v)
+		default:
+			return nil, fmt.Errorf("ssgo: bad URL %q: unknown parameter %q", redacted, k)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ssgo: bad URL %q: parameter %s: %w", redacted, k, err)
+		}
+	}
+	if useTLS {
+		uo.TLSConfig = &tlsCfg
+	}
+	return uo, nil
+}
+
+// redactURL formats u for error messages, with its password, in the user
+// info or the query, replaced by "xxxxx".
+func redactURL(u *url.URL) string {
+	ru := *u
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			ru.User = url.UserPassword(u.User.Username(), "xxxxx")
+		} else {
+			ru.User = url.User("xxxxx")
+		}
+	}
+	if q := u.Query(); q.Has("password") {
+		q.Set("password", "xxxxx")
+		ru.RawQuery = q.Encode()
+	}
+	return ru.String()
+}
+
+// NewConPoolFromURL creates a ConPool configured by uo. opts are applied
+// on top, for settings a URL cannot carry such as a Dialer.
+func NewConPoolFromURL(uo *URLOptions, opts ...Option) *ConPool {
+	base := uo.Options
+	opts = append([]Option{func(o *Options) { *o = base }}, opts...)
+	return NewConPool(uo.Network+"://"+uo.Addr, uo.MaxConn, opts...)
+}
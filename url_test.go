@@ -0,0 +1,107 @@
+package ssgo
+
+import (
+	"crypto/tls"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var parseURLTests = []struct {
+	url  string
+	want *URLOptions
+}{
+	{
+		"ssdb://:secret@db.example.com:8889?max_conn=32&dial_timeout=2s&read_timeout=1s&tls=true",
+		&URLOptions{Network: "tcp", Addr: "db.example.com:8889", MaxConn: 32, Options: Options{
+			Password:    "secret",
+			DialTimeout: 2 * time.Second,
+			ReadTimeout: time.Second,
+			TLSConfig:   &tls.Config{},
+		}},
+	},
+	{
+		"ssdbs://db.example.com?tls_server_name=ssdb&max_active=8&wait_timeout=100ms&test_on_borrow=1",
+		&URLOptions{Network: "tcp", Addr: "db.example.com:8888", Options: Options{
+			MaxActive:    8,
+			WaitTimeout:  100 * time.Millisecond,
+			TestOnBorrow: true,
+			TLSConfig:    &tls.Config{ServerName: "ssdb"},
+		}},
+	},
+	{
+		"unix:///var/run/ssdb.sock?password=secret&idle_timeout=1m&max_lifetime=1h&batch_chunk_size=64",
+		&URLOptions{Network: "unix", Addr: "/var/run/ssdb.sock", Options: Options{
+			Password:       "secret",
+			IdleTimeout:    time.Minute,
+			MaxLifetime:    time.Hour,
+			BatchChunkSize: 64,
+		}},
+	},
+}
+
+func TestParseURL(t *testing.T) {
+	for _, tt := range parseURLTests {
+		got, err := ParseURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseURL(%q) returned error %v", tt.url, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseURL(%q) returned %+v, want %+v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestParseURLErrors(t *testing.T) {
+	for url, msg := range map[string]string{
+		"redis://localhost":                  "unsupported scheme",
+		"ssdb://localhost?max_conns=3":       `unknown parameter "max_conns"`,
+		"ssdb://localhost?read_timeout=1":    "parameter read_timeout",
+		"ssdb://localhost?tls=maybe":         "parameter tls",
+		"ssdb:///no/host":                    "missing host",
+		"unix://?dial_timeout=1s":            "missing socket path",
+		"ssdb://localhost?max_active=lots&x": "parameter",
+	} {
+		_, err := ParseURL(url)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("ParseURL(%q) returned %v, want an error containing %q", url, err, msg)
+		}
+	}
+}
+
+func TestParseURLErrorsRedacted(t *testing.T) {
+	for _, url := range []string{
+		"ssdb://:hunter2@host:8888?bogus=1",
+		"ssdb://hunter2@host:8888?bogus=1",
+		"ssdb://host?password=hunter2&bogus=1",
+		"redis://:hunter2@host",
+		"ssdb://:hunter2@host:port",
+		"ssdb://:hunter2@host/\x7f",
+	} {
+		_, err := ParseURL(url)
+		if err == nil || strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("ParseURL(%q) returned %v", url, err)
+		}
+	}
+}
+
+func TestNewConPoolFromURL(t *testing.T) {
+	srv := newAuthTestServer(t, "secret", func(req []string) []string {
+		return []string{"ok", "pong"}
+	})
+	uo, err := ParseURL("ssdb://:secret@" + srv.Addr() + "?max_conn=2&read_timeout=1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewConPoolFromURL(uo, WithWriteTimeout(time.Second))
+	defer p.Close()
+
+	if cap(p.conns) != 2 || p.opts.ReadTimeout != time.Second || p.opts.WriteTimeout != time.Second {
+		t.Fatalf("pool not configured from URL: %+v", p.opts)
+	}
+	if r, err := p.Do("ping"); err != nil || r.String() != "pong" {
+		t.Fatalf("Do returned %v, %v", r, err)
+	}
+}
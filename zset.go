@@ -0,0 +1,189 @@
+package ssgo
+
+// Score bounds of ZScan, ZRScan and ZKeys are inclusive. Pass math.MinInt64
+// and math.MaxInt64 to leave them open.
+
+// ZSet sets the score of key in sorted set name.
+func (c commands) ZSet(name, key string, score int64) error {
+	_, err := c.call("zset", name, key, score)
+	return err
+}
+
+// ZGet returns the score of key in sorted set name. The error matches
+// ErrNotFound if key is not a member.
+func (c commands) ZGet(name, key string) (int64, error) {
+	r, err := c.call("zget", name, key)
+	return replyInt64("zget", r, err)
+}
+
+// ZDel removes key from sorted set name.
+func (c commands) ZDel(name, key string) error {
+	_, err := c.call("zdel", name, key)
+	return err
+}
+
+// ZIncr adds n to the score of key in sorted set name and returns the new
+// score.
+func (c commands) ZIncr(name, key string, n int64) (int64, error) {
+	r, err := c.call("zincr", name, key, n)
+	return replyInt64("zincr", r, err)
+}
+
+// ZExists reports whether key is a member of sorted set name.
+func (c commands) ZExists(name, key string) (bool, error) {
+	r, err := c.call("zexists", name, key)
+	return replyBool("zexists", r, err)
+}
+
+// ZSize returns the number of members in sorted set name.
+func (c commands) ZSize(name string) (int64, error) {
+	r, err := c.call("zsize", name)
+	return replyInt64("zsize", r, err)
+}
+
+// ZRank returns the 0-based position of key in sorted set name, by
+// ascending score. The error matches ErrNotFound if key is not a member.
+func (c commands) ZRank(name, key string) (int64, error) {
+	r, err := c.call("zrank", name, key)
+	return replyInt64("zrank", r, err)
+}
+
+// ZRRank is like ZRank, by descending score.
+func (c commands) ZRRank(name, key string) (int64, error) {
+	r, err := c.call("zrrank", name, key)
+	return replyInt64("zrrank", r, err)
+}
+
+// ZRange returns up to limit members of sorted set name from position
+// offset, by ascending score.
+func (c commands) ZRange(name string, offset, limit int) ([]ZEntry, error) {
+	r, err := c.call("zrange", name, offset, limit)
+	return replyZEntries("zrange", r, err)
+}
+
+// ZRRange is like ZRange, by descending score.
+func (c commands) ZRRange(name string, offset, limit int) ([]ZEntry, error) {
+	r, err := c.call("zrrange", name, offset, limit)
+	return replyZEntries("zrrange", r, err)
+}
+
+// ZScan returns up to limit members of sorted set name with scores in
+// [scoreStart, scoreEnd], by ascending score. A non-empty keyStart resumes
+// after that member, whose score should be passed as scoreStart.
+func (c commands) ZScan(name, keyStart string, scoreStart, scoreEnd int64, limit int) ([]ZEntry, error) {
+	r, err := c.call("zscan", name, keyStart, scoreStart, scoreEnd, limit)
+	return replyZEntries("zscan", r, err)
+}
+
+// ZRScan is like ZScan, by descending score; scoreStart is the upper bound.
+func (c commands) ZRScan(name, keyStart string, scoreStart, scoreEnd int64, limit int) ([]ZEntry, error) {
+	r, err := c.call("zrscan", name, keyStart, scoreStart, scoreEnd, limit)
+	return replyZEntries("zrscan", r, err)
+}
+
+// ZKeys is like ZScan, but returns only the member names.
+func (c commands) ZKeys(name, keyStart string, scoreStart, scoreEnd int64, limit int) ([]string, error) {
+	return replyList(c.call("zkeys", name, keyStart, scoreStart, scoreEnd, limit))
+}
+
+// ZList returns up to limit sorted set names in (start, end], in ascending
+// order.
+func (c commands) ZList(start, end string, limit int) ([]string, error) {
+	return replyList(c.call("zlist", start, end, limit))
+}
+
+// ZRList is like ZList, in descending order.
+func (c commands) ZRList(start, end string, limit int) ([]string, error) {
+	return replyList(c.call("zrlist", start, end, limit))
+}
+
+// ZClear deletes sorted set name and returns the number of members it had.
+func (c commands) ZClear(name string) (int64, error) {
+	r, err := c.call("zclear", name)
+	return replyInt64("zclear", r, err)
+}
+
+// ZCount returns the number of members of sorted set name with scores in
+// [scoreStart, scoreEnd].
+func (c commands) ZCount(name string, scoreStart, scoreEnd int64) (int64, error) {
+	r, err := c.call("zcount", name, scoreStart, scoreEnd)
+	return replyInt64("zcount", r, err)
+}
+
+// ZSum returns the sum of the scores in [scoreStart, scoreEnd] of sorted
+// set name.
+func (c commands) ZSum(name string, scoreStart, scoreEnd int64) (int64, error) {
+	r, err := c.call("zsum", name, scoreStart, scoreEnd)
+	return replyInt64("zsum", r, err)
+}
+
+// ZAvg returns the mean of the scores in [scoreStart, scoreEnd] of sorted
+// set name.
+func (c commands) ZAvg(name string, scoreStart, scoreEnd int64) (float64, error) {
+	r, err := c.call("zavg", name, scoreStart, scoreEnd)
+	return replyFloat64("zavg", r, err)
+}
+
+// ZRemRangeByRank removes the members of sorted set name at positions
+// start to end, both included, and returns how many were removed.
+func (c commands) ZRemRangeByRank(name string, start, end int64) (int64, error) {
+	r, err := c.call("zremrangebyrank", name, start, end)
+	return replyInt64("zremrangebyrank", r, err)
+}
+
+// ZRemRangeByScore removes the members of sorted set name with scores in
+// [scoreStart, scoreEnd], and returns how many were removed.
+func (c commands) ZRemRangeByScore(name string, scoreStart, scoreEnd int64) (int64, error) {
+	r, err := c.call("zremrangebyscore", name, scoreStart, scoreEnd)
+	return replyInt64("zremrangebyscore", r, err)
+}
+
+// ZPopFront removes and returns up to limit members of sorted set name
+// with the lowest scores.
+func (c commands) ZPopFront(name string, limit int) ([]ZEntry, error) {
+	r, err := c.call("zpop_front", name, limit)
+	return replyZEntries("zpop_front", r, err)
+}
+
+// ZPopBack removes and returns up to limit members of sorted set name with
+// the highest scores.
+func (c commands) ZPopBack(name string, limit int) ([]ZEntry, error) {
+	r, err := c.call("zpop_back", name, limit)
+	return replyZEntries("zpop_back", r, err)
+}
+
+// MultiZSet sets the score of every key of kvs in sorted set name.
+func (c commands) MultiZSet(name string, kvs map[string]int64) error {
+	if len(kvs) == 0 {
+		return nil
+	}
+	_, err := c.call(Args{"multi_zset", name}.AddFlat(kvs)...)
+	return err
+}
+
+// MultiZGet returns the scores of keys in sorted set name; non-members are
+// left out.
+func (c commands) MultiZGet(name string, keys ...string) (map[string]int64, error) {
+	if len(keys) == 0 {
+		return map[string]int64{}, nil
+	}
+	r, err := c.call(appendStrings([]interface{}{"multi_zget", name}, keys)...)
+	zs, err := replyZEntries("multi_zget", r, err)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]int64, len(zs))
+	for _, z := range zs {
+		m[z.Key] = z.Score
+	}
+	return m, nil
+}
+
+// MultiZDel removes keys from sorted set name.
+func (c commands) MultiZDel(name string, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := c.call(appendStrings([]interface{}{"multi_zdel", name}, keys)...)
+	return err
+}
@@ -0,0 +1,90 @@
+package ssgo
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestZSetCommands(t *testing.T) {
+	var last []string
+	srv := newTestServer(t, func(req []string) []string {
+		last = req
+		switch req[0] {
+		case "zrank":
+			return []string{"not_found"}
+		case "zrange", "zscan", "multi_zget":
+			return []string{"ok", "a", "-1", "b", "20"}
+		case "zrrange":
+			return []string{"ok", "a", "x"}
+		}
+		return []string{"ok", "1"}
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	if _, err := p.ZRank("z", "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ZRank returned %v, want %v", err, ErrNotFound)
+	}
+	want := []ZEntry{{"a", -1}, {"b", 20}}
+	zs, err := p.ZRange("z", 0, 10)
+	if err != nil || !reflect.DeepEqual(zs, want) {
+		t.Fatalf("ZRange returned %v, %v", zs, err)
+	}
+	if _, err := p.ZRRange("z", 0, 10); !errors.Is(err, ErrBadReply) {
+		t.Fatalf("ZRRange returned %v, want %v", err, ErrBadReply)
+	}
+	zs, err = p.ZScan("z", "", math.MinInt64, math.MaxInt64, 10)
+	if err != nil || !reflect.DeepEqual(zs, want) {
+		t.Fatalf("ZScan returned %v, %v", zs, err)
+	}
+	if !reflect.DeepEqual(last, []string{"zscan", "z", "", "-9223372036854775808", "9223372036854775807", "10"}) {
+		t.Fatalf("sent %q", last)
+	}
+	m, err := p.MultiZGet("z", "a", "b")
+	if err != nil || !reflect.DeepEqual(m, map[string]int64{"a": -1, "b": 20}) {
+		t.Fatalf("MultiZGet returned %v, %v", m, err)
+	}
+	if n, err := p.ZIncr("z", "a", 5); n != 1 || err != nil {
+		t.Fatalf("ZIncr returned %v, %v", n, err)
+	}
+}
+
+func TestZSetAggregates(t *testing.T) {
+	var last []string
+	srv := newTestServer(t, func(req []string) []string {
+		last = req
+		switch req[0] {
+		case "zavg":
+			return []string{"ok", "2.5"}
+		case "zsum":
+			return []string{"ok", ""}
+		case "zpop_back":
+			return []string{"ok", "b", "20"}
+		}
+		return []string{"ok", "4"}
+	})
+	p := NewConPool(srv.Addr(), 1)
+	defer p.Close()
+
+	if n, err := p.ZCount("z", 0, 100); n != 4 || err != nil {
+		t.Fatalf("ZCount returned %v, %v", n, err)
+	}
+	if _, err := p.ZSum("z", 0, 100); !errors.Is(err, ErrBadReply) {
+		t.Fatalf("ZSum returned %v, want %v", err, ErrBadReply)
+	}
+	if f, err := p.ZAvg("z", 0, 100); f != 2.5 || err != nil {
+		t.Fatalf("ZAvg returned %v, %v", f, err)
+	}
+	if n, err := p.ZRemRangeByRank("z", 0, -11); n != 4 || err != nil {
+		t.Fatalf("ZRemRangeByRank returned %v, %v", n, err)
+	}
+	if !reflect.DeepEqual(last, []string{"zremrangebyrank", "z", "0", "-11"}) {
+		t.Fatalf("sent %q", last)
+	}
+	zs, err := p.ZPopBack("z", 1)
+	if err != nil || !reflect.DeepEqual(zs, []ZEntry{{"b", 20}}) {
+		t.Fatalf("ZPopBack returned %v, %v", zs, err)
+	}
+}